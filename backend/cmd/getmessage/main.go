@@ -4,6 +4,7 @@ package main
 import (
 	"flag"
 	"log"
+	"log/slog"
 	"os"
 	"strings"
 	"time"
@@ -34,7 +35,7 @@ func main() {
 		RateLimit:      time.Second,
 		MaxConcurrency: 5,
 	}
-	client := slack.NewClient(config)
+	client := slack.NewClient(config, slack.WithLogger(slog.Default()))
 
 	// トークンの検証
 	if err := client.Validate(); err != nil {
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+
+	"github.com/Tattsum/wordcloud/backend/pkg/wordcloud"
+)
+
+// runAnalyze はCSVファイルの単語を集計してワードクラウドデータのJSONを出力する
+//
+//	wordcloud analyze -input messages.csv -output wordcloud.json
+func runAnalyze(args []string) {
+	fs, s := newCommand("analyze", "[flags]", args)
+	input := fs.String("input", "", "Input CSV file path")
+	output := fs.String("output", "-", "Output JSON file path (- for stdout)")
+	keywords := fs.String("keywords", "", "Rank key phrases instead of frequent words (textrank/rake)")
+	registerAnalyze(fs, &s.Analyze)
+	registerRender(fs, &s.Render)
+	registerTimezone(fs, s)
+	fs.Parse(args)
+
+	if *input == "" {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	processor := newProcessor(s)
+	wordCounts := countWords(processor, *input, *keywords)
+
+	if *output == "-" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(wordCounts); err != nil {
+			log.Fatalf("JSONの出力に失敗: %v", err)
+		}
+		return
+	}
+	if err := processor.ExportJSON(wordCounts, *output); err != nil {
+		log.Fatalf("JSONの出力に失敗: %v", err)
+	}
+	log.Printf("ワードクラウドデータの出力が完了しました: %s", *output)
+}
+
+// countWords はCSVファイルの単語を集計する（キーワード抽出が指定された場合は頻出語の代わりにキーワードを使う）
+// 単語がない場合は警告を表示して空のデータを返す
+func countWords(processor *wordcloud.FileProcessor, input, keywords string) []wordcloud.WordCount {
+	var wordCounts []wordcloud.WordCount
+	var err error
+	if keywords != "" {
+		opts := wordcloud.DefaultKeywordOptions()
+		opts.Method = keywords
+		wordCounts, err = processor.KeywordsForCSV(input, messageColumn, opts)
+	} else {
+		wordCounts, err = processor.ProcessCSV(input, messageColumn, wordcloud.WithProgress(newLogProgress()))
+	}
+	if wordcloud.IsEmptyError(err) {
+		log.Printf("警告: %v。-min-count や -filter の指定を見直してください", err)
+		return []wordcloud.WordCount{}
+	}
+	if err != nil {
+		log.Fatalf("CSVファイルの処理に失敗: %v", err)
+	}
+	return wordCounts
+}
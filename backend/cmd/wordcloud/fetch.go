@@ -0,0 +1,51 @@
+package main
+
+import (
+	"log"
+	"os"
+	"strings"
+
+	"github.com/Tattsum/wordcloud/backend/pkg/slack"
+)
+
+// runFetch はSlackチャンネルのメッセージをCSVファイルに出力する
+// トークンは環境変数SLACK_TOKENまたは設定ファイルで指定できる
+//
+//	SLACK_TOKEN=xoxb-... wordcloud fetch -channel C1234567890 -output data
+func runFetch(args []string) {
+	fs, s := newCommand("fetch", "[flags]", args)
+	token := registerSlack(fs, &s.Slack)
+	fs.StringVar(&s.Slack.OutputDir, "output", s.Slack.OutputDir, "Output directory path")
+	registerTimezone(fs, s)
+	fs.Parse(args)
+
+	if *token != "" {
+		s.Slack.Token = *token
+	}
+	s.Slack.Channel = strings.TrimSpace(s.Slack.Channel)
+	config := s.ClientConfig()
+	if config.Token == "" || s.Slack.Channel == "" {
+		log.Println("Error: token and channel are required (set SLACK_TOKEN or slack.token in the config file)")
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	options, err := s.ExportOptions()
+	if err != nil {
+		log.Fatalf("出力設定の読み込みに失敗: %v", err)
+	}
+
+	options = append(options, slack.WithExportProgress(newLogProgress()))
+
+	client := newClient(s)
+	if err := client.Validate(); err != nil {
+		log.Fatalf("Slackトークンの検証に失敗: %v", err)
+	}
+
+	outputPath, err := client.ExportChannelMessages(s.Slack.Channel, options...)
+	if err != nil {
+		log.Fatalf("メッセージの出力に失敗: %v", err)
+	}
+
+	log.Printf("メッセージの出力が完了しました: %s", outputPath)
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/Tattsum/wordcloud/backend/pkg/settings"
+	"github.com/Tattsum/wordcloud/backend/pkg/slack"
+	"github.com/Tattsum/wordcloud/backend/pkg/wordcloud"
+)
+
+// 設定ファイルとプロファイルを指定する環境変数
+const (
+	envConfig  = settings.EnvPrefix + "CONFIG"
+	envProfile = settings.EnvPrefix + "PROFILE"
+)
+
+// newCommand はサブコマンドのFlagSetと、設定ファイル・プロファイル・環境変数を反映した設定を作成する
+// フラグの既定値には読み込んだ設定の値を使うため、コマンドラインの指定が最も優先される
+func newCommand(name, usage string, args []string) (*flag.FlagSet, *settings.Settings) {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: wordcloud %s %s\n", name, usage)
+		fs.PrintDefaults()
+	}
+	return fs, loadSettings(fs, args)
+}
+
+// loadSettings は引数と環境変数から設定ファイルとプロファイルを決めて設定を読み込み、
+// -config・-profile・-log-format・-log-levelフラグをFlagSetに登録する
+// ログの設定から作成したロガーは既定のロガーとして使う
+func loadSettings(fs *flag.FlagSet, args []string) *settings.Settings {
+	path, profile := os.Getenv(envConfig), os.Getenv(envProfile)
+	if v, ok := lookupArg(args, "config"); ok {
+		path = v
+	}
+	if v, ok := lookupArg(args, "profile"); ok {
+		profile = v
+	}
+
+	s, err := settings.Load(path, profile)
+	if err != nil {
+		log.Fatalf("設定の読み込みに失敗: %v", err)
+	}
+
+	if v, ok := lookupArg(args, "log-format"); ok {
+		s.Log.Format = v
+	}
+	if v, ok := lookupArg(args, "log-level"); ok {
+		s.Log.Level = v
+	}
+	logger, err := s.Logger(os.Stderr)
+	if err != nil {
+		log.Fatalf("ログの設定に失敗: %v", err)
+	}
+	slog.SetDefault(logger)
+
+	// 値は先に読み込んでいるため、フラグはパースエラーを避けるためだけに登録する
+	fs.String("config", path, "Config file (YAML, TOML or JSON; also "+envConfig+")")
+	fs.String("profile", profile, "Profile in the config file to apply (also "+envProfile+")")
+	fs.String("log-format", s.Log.Format, "Log format (text/json)")
+	fs.String("log-level", s.Log.Level, "Minimum log level (debug/info/warn/error)")
+	return s
+}
+
+// lookupArg はフラグをパースする前に引数から -name の値を探す
+func lookupArg(args []string, name string) (string, bool) {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			break
+		}
+		trimmed := strings.TrimLeft(arg, "-")
+		if trimmed == arg || len(arg)-len(trimmed) > 2 {
+			continue
+		}
+		if trimmed == name && i+1 < len(args) {
+			return args[i+1], true
+		}
+		if value, ok := strings.CutPrefix(trimmed, name+"="); ok {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// registerTimezone はタイムゾーンのフラグを登録
+func registerTimezone(fs *flag.FlagSet, s *settings.Settings) {
+	fs.StringVar(&s.Timezone, "timezone", s.Timezone, "Time zone used to bucket message timestamps")
+}
+
+// registerAnalyze は単語の集計に関するフラグを登録
+func registerAnalyze(fs *flag.FlagSet, a *settings.Analyze) {
+	fs.IntVar(&a.MinCount, "min-count", a.MinCount, "Minimum word count")
+	fs.IntVar(&a.MaxWords, "max-words", a.MaxWords, "Maximum number of words")
+	fs.Var(&a.StopWords, "stopwords", "Comma-separated stop word sources (preset:ja, preset:en, preset:slack or file paths)")
+	fs.Var(&a.Normalize, "normalize", "Text normalizers applied before tokenization (nfkc,code,slack,variant or none)")
+	fs.StringVar(&a.Synonyms, "synonyms", a.Synonyms, "Synonym file path (lines of \"canonical = alias1, alias2\")")
+	fs.StringVar(&a.Weighting, "weighting", a.Weighting, "Word weighting (count/tfidf/loglikelihood)")
+	fs.StringVar(&a.DocumentUnit, "document-unit", a.DocumentUnit, "Document unit for TF-IDF (message/thread/day)")
+	fs.StringVar(&a.Reference, "reference", a.Reference, "Reference corpus file for log-likelihood weighting (word<TAB>count lines or wordcloud JSON)")
+	fs.IntVar(&a.NGram, "ngram", a.NGram, "Maximum phrase length in words to extract (2 or 3, 0 disables phrases)")
+	fs.IntVar(&a.MaxPhrases, "max-phrases", a.MaxPhrases, "Maximum number of phrases mixed into the output")
+	fs.Var(&a.ExcludeUsers, "exclude-users", "Comma-separated user IDs or names to exclude")
+	fs.StringVar(&a.Filter, "filter", a.Filter, `Message filter expression (e.g. 'user!=U123 && date>=2026-01-01 && !text~"^<@"')`)
+	fs.StringVar(&a.Sentiment, "sentiment", a.Sentiment, "Sentiment lexicon file (word<TAB>polarity lines) used to score messages; -color sentiment uses a built-in lexicon when omitted")
+}
+
+// registerRender は画像の描画に関するフラグを登録
+func registerRender(fs *flag.FlagSet, r *settings.Render) {
+	fs.IntVar(&r.Width, "width", r.Width, "Image width in pixels")
+	fs.IntVar(&r.Height, "height", r.Height, "Image height in pixels")
+	fs.StringVar(&r.Font, "font", r.Font, "TrueType font file path")
+	fs.IntVar(&r.MinFontSize, "min-font", r.MinFontSize, "Minimum font size")
+	fs.IntVar(&r.MaxFontSize, "max-font", r.MaxFontSize, "Maximum font size")
+	fs.StringVar(&r.FontScale, "font-scale", r.FontScale, "Font size scaling (linear/log/sqrt/rank/relative, default log for counts and linear otherwise)")
+	fs.Float64Var(&r.RelativeScaling, "relative-scaling", r.RelativeScaling, "How strongly -font-scale relative follows the value ratio between neighboring words (0-1)")
+	fs.BoolVar(&r.Fit, "fit", r.Fit, "Scale all font sizes so the words fill the canvas")
+	fs.StringVar(&r.Color, "color", r.Color, "Color scheme (blue/rainbow/sequential/diverging/categorical/viridis/random/pos/user/sentiment)")
+	fs.StringVar(&r.Palette, "palette", r.Palette, "Palette for the color scheme (preset:NAME, comma-separated colors or a file with one color per line)")
+	fs.StringVar(&r.Background, "background", r.Background, "Background color")
+	fs.Float64Var(&r.MinContrast, "min-contrast", r.MinContrast, "Minimum contrast ratio between words and the background (negative disables adjustment)")
+}
+
+// registerSlack はSlackからのメッセージ取得に関するフラグを登録
+// トークンは既定値として表示しないように別の変数で受け取り、指定された場合だけ設定を上書きする
+func registerSlack(fs *flag.FlagSet, sl *settings.Slack) *string {
+	token := fs.String("token", "", "Slack Bot User OAuth Token (prefer SLACK_TOKEN or the config file)")
+	fs.StringVar(&sl.Channel, "channel", sl.Channel, "Channel ID (also SLACK_CHANNEL)")
+	fs.Var(&sl.RateLimit, "rate-limit", "Minimum interval between Slack API calls")
+	fs.IntVar(&sl.MaxConcurrency, "max-concurrency", sl.MaxConcurrency, "Maximum number of concurrent Slack API calls")
+	fs.BoolVar(&sl.IncludeThread, "thread", sl.IncludeThread, "Include the ThreadTS column in the CSV")
+	fs.StringVar(&sl.APIURL, "slack-api-url", sl.APIURL, "Base URL of the Slack API (e.g. a local fake server)")
+	return token
+}
+
+// newClient は設定からSlackクライアントを作成する（ログは既定のロガーに出力する）
+func newClient(s *settings.Settings) *slack.Client {
+	return slack.NewClient(s.ClientConfig(), slack.WithLogger(slog.Default()))
+}
+
+// newProcessor は設定からFileProcessorを作成
+func newProcessor(s *settings.Settings, modify ...func(*wordcloud.Config)) *wordcloud.FileProcessor {
+	processor, err := s.NewFileProcessor(modify...)
+	if err != nil {
+		log.Fatalf("プロセッサーの初期化に失敗: %v", err)
+	}
+	return processor
+}
+
+// generateIndex はCSVファイルからワードクラウドデータと単語の逆引き索引を作成
+func generateIndex(s *settings.Settings, input string) (*wordcloud.Generator, *wordcloud.WordIndex, []wordcloud.WordCount) {
+	processor := newProcessor(s)
+	progress := wordcloud.WithProgress(newLogProgress())
+	messages, err := processor.ReadCSV(input, messageColumn, progress)
+	if err != nil {
+		log.Fatalf("CSVファイルの処理に失敗: %v", err)
+	}
+
+	// 最小出現回数に達する単語がなくても索引は作成する
+	generator := processor.Generator()
+	wordCounts, err := generator.GenerateMessages(messages, progress)
+	if err != nil && !errors.Is(err, wordcloud.ErrNoWords) {
+		log.Fatalf("CSVファイルの処理に失敗: %v", err)
+	}
+	return generator, generator.BuildIndex(messages), wordCounts
+}
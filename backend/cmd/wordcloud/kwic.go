@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+// runKWIC は単語の出現箇所を前後の文脈とともに表示する
+//
+//	wordcloud kwic -input messages.csv 障害
+func runKWIC(args []string) {
+	fs, s := newCommand("kwic", "[flags] <word>", args)
+	input := fs.String("input", "", "Input CSV file path")
+	registerAnalyze(fs, &s.Analyze)
+	registerTimezone(fs, s)
+	width := fs.Int("width", 20, "Number of context characters on each side")
+	limit := fs.Int("limit", 50, "Maximum number of lines (0 for all)")
+
+	// 単語の後ろに書かれたフラグも受け付ける
+	fs.Parse(args)
+	word := fs.Arg(0)
+	if fs.NArg() > 1 {
+		fs.Parse(fs.Args()[1:])
+	}
+
+	if *input == "" || word == "" {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	generator, index, _ := generateIndex(s, *input)
+	key := generator.ResolveWord(word)
+	lines := index.Concordance(key, *width, *limit)
+	if len(lines) == 0 {
+		log.Fatalf("単語が見つかりません: %s", key)
+	}
+
+	for _, line := range lines {
+		left := strings.Repeat(" ", *width-utf8.RuneCountInString(line.Left)) + line.Left
+		fmt.Printf("%6d  %s [%s] %s\n", line.Ref.Row, left, line.Keyword, line.Right)
+	}
+	log.Printf("%s: %d 件のメッセージに出現（%d 行を表示）", key, len(index.Lookup(key)), len(lines))
+}
@@ -2,22 +2,81 @@ package main
 
 import (
 	"flag"
+	"fmt"
 	"log"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/Tattsum/wordcloud/backend/pkg/wordcloud"
 )
 
+// messageColumn はCSVのメッセージカラムのインデックス
+const messageColumn = 3
+
+// commands はサブコマンドと実行する関数の対応
+var commands = map[string]func(args []string){
+	"fetch":    runFetch,
+	"pipeline": runPipeline,
+	"analyze":  runAnalyze,
+	"render":   runRender,
+	"stats":    runStats,
+	"serve":    runServe,
+	"kwic":     runKWIC,
+}
+
+// commandNames はヘルプに表示するサブコマンドの一覧
+const commandNames = `Commands:
+  fetch    Export Slack channel messages to CSV
+  pipeline Fetch a channel period and write PNG/SVG/JSON without an intermediate CSV
+  analyze  Count words in a CSV and write wordcloud JSON
+  render   Render a CSV or wordcloud JSON as PNG or SVG
+  stats    Print message and word statistics for a CSV
+  serve    Serve wordcloud data and word contexts over HTTP
+  kwic     Show the contexts a word appears in
+
+Run "wordcloud <command> -h" for the flags of each command.
+Running without a command accepts the flags below for trends, topics, networks and comparisons.`
+
 func main() {
+	if len(os.Args) > 1 {
+		if run, ok := commands[os.Args[1]]; ok {
+			run(os.Args[2:])
+			return
+		}
+		if os.Args[1] == "help" || !strings.HasPrefix(os.Args[1], "-") {
+			fmt.Fprintf(os.Stderr, "Usage: wordcloud <command> [flags]\n\n%s\n", commandNames)
+			os.Exit(2)
+		}
+	}
+
+	s := loadSettings(flag.CommandLine, os.Args[1:])
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: wordcloud <command> [flags] | wordcloud [flags]\n\n%s\n\n", commandNames)
+		flag.PrintDefaults()
+	}
+	registerAnalyze(flag.CommandLine, &s.Analyze)
+	registerRender(flag.CommandLine, &s.Render)
+	registerTimezone(flag.CommandLine, s)
+
 	var (
 		inputFile   = flag.String("input", "", "Input CSV file path")
 		outputFile  = flag.String("output", "", "Output PNG file path")
-		minCount    = flag.Int("min-count", 2, "Minimum word count")
-		maxWords    = flag.Int("max-words", 100, "Maximum number of words")
-		colorScheme = flag.String("color", "blue", "Color scheme (blue/rainbow)")
-		width       = flag.Int("width", 800, "Image width in pixels")
-		height      = flag.Int("height", 600, "Image height in pixels")
+		jsonFile    = flag.String("json", "", "Optional output JSON file path for -trends, -topics and -network")
+		compareFile = flag.String("compare", "", "Second input CSV file to compare against (enables comparison mode)")
+		compareMode = flag.String("compare-mode", wordcloud.ComparisonSplit, "Comparison cloud mode (split/commonality)")
+		trends      = flag.String("trends", "", "Export word trends over time instead of a cloud (day/week/month)")
+		animate     = flag.String("animate", "", "Export an animated cloud over the -trends periods (gif/apng)")
+		trendsCSV   = flag.String("trends-csv", "", "Optional output CSV file path for word trends")
+		network     = flag.String("network", "", "Export a co-occurrence network weighted by jaccard or pmi (format from -output extension: png/svg/graphml/gexf/json)")
+		netWindow   = flag.Int("network-window", 0, "Maximum word distance counted as co-occurrence (0 uses the whole message)")
+		keywords    = flag.String("keywords", "", "Render ranked key phrases instead of frequent words (textrank/rake)")
+		topics      = flag.Int("topics", 0, "Estimate this many LDA topics and write one small cloud per topic in a grid")
+		topicSeed   = flag.Int64("topic-seed", 1, "Random seed for topic estimation")
+		topicIters  = flag.Int("topic-iterations", 200, "Number of Gibbs sampling iterations for topic estimation")
+		topicsCSV   = flag.String("topics-csv", "", "Optional output CSV file path for per-message topic distributions")
+		groupBy     = flag.String("group-by", "", "Write one cloud per user or team into the -output directory (user/team)")
+		teamsFile   = flag.String("teams", "", "User to team mapping CSV (user_id,team) used with -group-by team")
 	)
 
 	flag.Parse()
@@ -27,38 +86,183 @@ func main() {
 		os.Exit(1)
 	}
 
-	// 設定の初期化
-	config := wordcloud.Config{
-		MinCount:    *minCount,
-		MaxWords:    *maxWords,
-		MinFontSize: 12,
-		MaxFontSize: 48,
-		ColorScheme: *colorScheme,
-		Width:       *width,
-		Height:      *height,
+	// プロセッサーの初期化
+	processor := newProcessor(s)
+
+	if *groupBy != "" {
+		runGroups(processor, *inputFile, *groupBy, *teamsFile, *outputFile)
+		return
 	}
 
-	// プロセッサーの初期化
-	processor, err := wordcloud.NewFileProcessor(config)
+	// 出力ディレクトリの作成
+	if err := os.MkdirAll(filepath.Dir(*outputFile), 0755); err != nil {
+		log.Fatalf("出力ディレクトリの作成に失敗: %v", err)
+	}
+
+	if *trends != "" {
+		runTrends(processor, *inputFile, *trends, *animate, *outputFile, *jsonFile, *trendsCSV)
+		return
+	}
+
+	if *topics > 0 {
+		opts := wordcloud.DefaultTopicOptions()
+		opts.Topics = *topics
+		opts.Seed = *topicSeed
+		opts.Iterations = *topicIters
+		opts.Progress = newLogProgress()
+		runTopics(processor, *inputFile, opts, *outputFile, *jsonFile, *topicsCSV)
+		return
+	}
+
+	if *network != "" {
+		runNetwork(processor, *inputFile, *network, *netWindow, *outputFile, *jsonFile)
+		return
+	}
+
+	if *compareFile != "" {
+		runComparison(processor, *inputFile, *compareFile, *compareMode, *outputFile)
+		return
+	}
+
+	wordCounts := countWords(processor, *inputFile, *keywords)
+	exportImage(processor, wordCounts, *outputFile)
+}
+
+// runComparison は2つのCSVファイルを比較したワードクラウドを出力
+func runComparison(processor *wordcloud.FileProcessor, inputA, inputB, mode, outputFile string) {
+	comparison, err := processor.CompareCSV(inputA, inputB, messageColumn)
 	if err != nil {
-		log.Fatalf("プロセッサーの初期化に失敗: %v", err)
+		log.Fatalf("CSVファイルの比較に失敗: %v", err)
 	}
 
-	// CSVファイルの処理
-	wordCounts, err := processor.ProcessCSV(*inputFile, 3) // 3はメッセージカラムのインデックス
+	switch mode {
+	case wordcloud.ComparisonSplit:
+		err = processor.ExportComparisonPNG(comparison, outputFile)
+	case wordcloud.ComparisonCommonality:
+		var words []wordcloud.WordCount
+		if words, err = processor.Generator().CommonalityCloud(comparison); err == nil {
+			err = processor.ExportPNG(words, outputFile)
+		}
+	default:
+		log.Fatalf("不明な比較モードです: %s", mode)
+	}
 	if err != nil {
-		log.Fatalf("CSVファイルの処理に失敗: %v", err)
+		log.Fatalf("PNG画像の出力に失敗: %v", err)
 	}
 
-	// 出力ディレクトリの作成
-	if err := os.MkdirAll(filepath.Dir(*outputFile), 0755); err != nil {
-		log.Fatalf("出力ディレクトリの作成に失敗: %v", err)
+	log.Printf("比較ワードクラウド画像の生成が完了しました: %s", outputFile)
+}
+
+// runTopics はトピックを推定してトピックごとのワードクラウドを出力
+func runTopics(processor *wordcloud.FileProcessor, inputFile string, opts wordcloud.TopicOptions, outputFile, jsonFile, csvFile string) {
+	model, err := processor.TopicsForCSV(inputFile, messageColumn, opts)
+	if err != nil {
+		log.Fatalf("トピックの推定に失敗: %v", err)
+	}
+
+	if jsonFile != "" {
+		if err := processor.ExportTopicsJSON(model, jsonFile); err != nil {
+			log.Fatalf("JSONの出力に失敗: %v", err)
+		}
+	}
+	if csvFile != "" {
+		if err := processor.ExportTopicsCSV(model, csvFile); err != nil {
+			log.Fatalf("CSVの出力に失敗: %v", err)
+		}
+	}
+
+	if err := processor.ExportTopicsPNG(model, outputFile); err != nil {
+		log.Fatalf("PNG画像の出力に失敗: %v", err)
+	}
+
+	log.Printf("トピックごとのワードクラウドの生成が完了しました: %s", outputFile)
+}
+
+// runNetwork は単語の共起ネットワークを集計して出力
+func runNetwork(processor *wordcloud.FileProcessor, inputFile, measure string, window int, outputFile, jsonFile string) {
+	opts := wordcloud.DefaultCooccurrenceOptions()
+	opts.Measure = measure
+	opts.Window = window
+
+	network, err := processor.CooccurrenceForCSV(inputFile, messageColumn, opts)
+	if err != nil {
+		log.Fatalf("共起ネットワークの集計に失敗: %v", err)
+	}
+
+	if jsonFile != "" {
+		if err := processor.ExportNetwork(network, jsonFile); err != nil {
+			log.Fatalf("JSONの出力に失敗: %v", err)
+		}
+	}
+	if err := processor.ExportNetwork(network, outputFile); err != nil {
+		log.Fatalf("共起ネットワークの出力に失敗: %v", err)
+	}
+
+	log.Printf("共起ネットワークの生成が完了しました: %s", outputFile)
+}
+
+// trendChartSeries は推移の折れ線グラフに描画する単語数
+const trendChartSeries = 8
+
+// runTrends は単語の出現回数の推移を集計して出力
+// animateがgifまたはapngの場合は折れ線グラフの代わりにアニメーションを出力する
+func runTrends(processor *wordcloud.FileProcessor, inputFile, period, animate, outputFile, jsonFile, csvFile string) {
+	trends, err := processor.TrendsForCSV(inputFile, messageColumn, period)
+	if err != nil {
+		log.Fatalf("時系列の集計に失敗: %v", err)
 	}
 
-	// PNGファイルとして出力
-	if err := processor.ExportPNG(wordCounts, *outputFile); err != nil {
+	if jsonFile != "" {
+		if err := processor.ExportTrendsJSON(trends, jsonFile); err != nil {
+			log.Fatalf("JSONの出力に失敗: %v", err)
+		}
+	}
+	if csvFile != "" {
+		if err := processor.ExportTrendsCSV(trends, csvFile); err != nil {
+			log.Fatalf("CSVの出力に失敗: %v", err)
+		}
+	}
+
+	if animate != "" {
+		opts := wordcloud.DefaultAnimationOptions()
+		opts.Format = animate
+		if err := processor.ExportAnimation(trends, outputFile, opts); err != nil {
+			log.Fatalf("アニメーションの出力に失敗: %v", err)
+		}
+		log.Printf("ワードクラウドのアニメーションの生成が完了しました: %s", outputFile)
+		return
+	}
+
+	if err := processor.ExportTrendsPNG(trends, outputFile, trendChartSeries); err != nil {
 		log.Fatalf("PNG画像の出力に失敗: %v", err)
 	}
 
-	log.Printf("ワードクラウド画像の生成が完了しました: %s", *outputFile)
+	log.Printf("単語の推移グラフの生成が完了しました: %s", outputFile)
+}
+
+// runGroups はユーザーまたはチームごとのワードクラウドをoutputDirに出力
+func runGroups(processor *wordcloud.FileProcessor, inputFile, groupBy, teamsFile, outputDir string) {
+	var opts wordcloud.GroupOptions
+	switch groupBy {
+	case "user":
+	case "team":
+		if teamsFile == "" {
+			log.Fatal("-group-by team には -teams の指定が必要です")
+		}
+		mapping, err := wordcloud.LoadGroupMapping(teamsFile)
+		if err != nil {
+			log.Fatalf("チーム対応表の読み込みに失敗: %v", err)
+		}
+		opts.Mapping = mapping
+		opts.UnmappedGroup = "other"
+	default:
+		log.Fatalf("不明なグループ化の単位です: %s", groupBy)
+	}
+
+	clouds, err := processor.ExportGroups(inputFile, messageColumn, outputDir, opts)
+	if err != nil {
+		log.Fatalf("グループごとのワードクラウドの出力に失敗: %v", err)
+	}
+
+	log.Printf("%d 件のワードクラウドの生成が完了しました: %s", len(clouds), outputDir)
 }
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"log"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Tattsum/wordcloud/backend/pkg/pipeline"
+	"github.com/Tattsum/wordcloud/backend/pkg/settings"
+)
+
+// runPipeline はSlackチャンネルの期間内のメッセージを取得し、CSVを経由せずにワードクラウドを出力する
+//
+//	SLACK_TOKEN=xoxb-... wordcloud pipeline -channel C1234567890 -from 7d -output data/week.png,data/week.json
+func runPipeline(args []string) {
+	fs, s := newCommand("pipeline", "[flags]", args)
+	token := registerSlack(fs, &s.Slack)
+	from := fs.String("from", "", "Start of the period (2026-01-01, RFC3339 or relative like 7d, 12h, 2w)")
+	to := fs.String("to", "", "End of the period; a date includes the whole day (empty means now)")
+	var outputs settings.List
+	outputs.Set("data/wordcloud.png")
+	fs.Var(&outputs, "output", "Comma-separated output files (.png, .svg or .json)")
+	fs.StringVar(&s.Slack.CacheDir, "cache", s.Slack.CacheDir, "Directory to cache fetched messages in (empty disables caching)")
+	fs.Var(&s.Slack.CacheTTL, "cache-ttl", "How long a cache covering the present is reused")
+	fs.StringVar(&s.Slack.PostChannel, "post", s.Slack.PostChannel, "Channel ID to post the first PNG to with a summary (empty disables posting)")
+	registerAnalyze(fs, &s.Analyze)
+	registerRender(fs, &s.Render)
+	registerTimezone(fs, s)
+	fs.Parse(args)
+
+	if *token != "" {
+		s.Slack.Token = *token
+	}
+	s.Slack.Channel = strings.TrimSpace(s.Slack.Channel)
+	if s.Slack.Token == "" || s.Slack.Channel == "" || len(outputs) == 0 {
+		log.Println("Error: token, channel and output are required (set SLACK_TOKEN or slack.token in the config file)")
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	location, err := s.Location()
+	if err != nil {
+		log.Fatal(err)
+	}
+	oldest, latest, err := pipeline.ParseRange(*from, *to, time.Now(), location)
+	if err != nil {
+		log.Fatalf("期間の指定が不正です: %v", err)
+	}
+
+	p := newPipeline(s)
+	result, err := p.Run(pipeline.Request{
+		Channel: s.Slack.Channel,
+		Oldest:  oldest,
+		Latest:  latest,
+		Outputs: outputs,
+		Post:    strings.TrimSpace(s.Slack.PostChannel),
+
+		Progress: newLogProgress(),
+	})
+	if err != nil {
+		log.Fatalf("ワードクラウドの作成に失敗: %v", err)
+	}
+
+	log.Printf("%d 件のメッセージから %d 語のワードクラウドを出力しました: %s",
+		len(result.Messages), len(result.Words), strings.Join(result.Outputs, ", "))
+	if result.Posted != nil {
+		log.Printf("チャンネル %s に投稿しました（ファイルID: %s）", s.Slack.PostChannel, result.Posted.ID)
+	}
+}
+
+// newPipeline は設定からSlackクライアントとFileProcessorを作成してPipelineを組み立てる
+func newPipeline(s *settings.Settings) *pipeline.Pipeline {
+	location, err := s.Location()
+	if err != nil {
+		log.Fatal(err)
+	}
+	options := []pipeline.Option{
+		pipeline.WithLocation(location),
+		pipeline.WithLogger(slog.Default()),
+	}
+	if s.Slack.CacheDir != "" {
+		options = append(options, pipeline.WithCache(s.Slack.CacheDir, time.Duration(s.Slack.CacheTTL)))
+	}
+	return pipeline.New(newClient(s), newProcessor(s), options...)
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"log/slog"
+	"sync"
+
+	"github.com/Tattsum/wordcloud/backend/pkg/slack"
+	"github.com/Tattsum/wordcloud/backend/pkg/wordcloud"
+)
+
+// stageLabels は進捗を表示する段階の名前
+var stageLabels = map[string]string{
+	wordcloud.StageReadCSV:   "CSVファイルの処理中",
+	wordcloud.StageAnalyze:   "テキスト解析中",
+	wordcloud.StageTopics:    "トピックの推定中",
+	slack.StageFetchMessages: "メッセージを取得中",
+}
+
+// logProgress は進捗を10%ごと（総数が不明な場合は通知のたび）にログに表示する
+type logProgress struct {
+	mutex sync.Mutex
+	last  map[string]int
+}
+
+// newLogProgress は新しいlogProgressを作成
+func newLogProgress() *logProgress {
+	return &logProgress{last: make(map[string]int)}
+}
+
+// Report は進捗をログに表示する
+func (p *logProgress) Report(stage string, done, total int) {
+	label := stageLabels[stage]
+	if label == "" {
+		label = stage
+	}
+	if total <= 0 {
+		slog.Info(label, "stage", stage, "done", done)
+		return
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	step := done * 10 / total
+	if done > 0 && step > p.last[stage] {
+		slog.Info(label, "stage", stage, "percent", done*100/total, "done", done, "total", total)
+	}
+	p.last[stage] = step
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Tattsum/wordcloud/backend/pkg/wordcloud"
+)
+
+// runRender はCSVファイルまたはanalyzeで出力したJSONからワードクラウド画像を出力する
+//
+//	wordcloud render -input wordcloud.json -output wordcloud.png
+func runRender(args []string) {
+	fs, s := newCommand("render", "[flags]", args)
+	input := fs.String("input", "", "Input CSV file or wordcloud JSON from analyze")
+	output := fs.String("output", "", "Output image path (.png or .svg)")
+	jsonFile := fs.String("json", "", "Optional output JSON file path (CSV input only)")
+	keywords := fs.String("keywords", "", "Render ranked key phrases instead of frequent words (textrank/rake, CSV input only)")
+	registerAnalyze(fs, &s.Analyze)
+	registerRender(fs, &s.Render)
+	registerTimezone(fs, s)
+	fs.Parse(args)
+
+	if *input == "" || *output == "" {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	processor := newProcessor(s)
+
+	var wordCounts []wordcloud.WordCount
+	if strings.EqualFold(filepath.Ext(*input), ".json") {
+		var err error
+		if wordCounts, err = processor.ReadJSON(*input); err != nil {
+			log.Fatalf("ワードクラウドデータの読み込みに失敗: %v", err)
+		}
+	} else {
+		wordCounts = countWords(processor, *input, *keywords)
+		if *jsonFile != "" {
+			if err := processor.ExportJSON(wordCounts, *jsonFile); err != nil {
+				log.Fatalf("JSONの出力に失敗: %v", err)
+			}
+		}
+	}
+
+	exportImage(processor, wordCounts, *output)
+}
+
+// exportImage は拡張子に応じてSVGまたはPNGファイルとして出力する
+// 単語がない場合はメッセージだけを表示した画像になる
+func exportImage(processor *wordcloud.FileProcessor, wordCounts []wordcloud.WordCount, output string) {
+	if err := os.MkdirAll(filepath.Dir(output), 0755); err != nil {
+		log.Fatalf("出力ディレクトリの作成に失敗: %v", err)
+	}
+
+	var err error
+	if strings.EqualFold(filepath.Ext(output), ".svg") {
+		err = processor.ExportSVG(wordCounts, output)
+	} else {
+		err = processor.ExportPNG(wordCounts, output)
+	}
+	if err != nil {
+		log.Fatalf("画像の出力に失敗: %v", err)
+	}
+
+	log.Printf("ワードクラウド画像の生成が完了しました: %s", output)
+}
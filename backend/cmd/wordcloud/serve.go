@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/Tattsum/wordcloud/backend/pkg/schedule"
+	"github.com/Tattsum/wordcloud/backend/pkg/server"
+	"github.com/Tattsum/wordcloud/backend/pkg/settings"
+)
+
+// runServe はCSVファイルから生成したワードクラウドデータと単語ごとのメッセージをHTTPで提供する
+// Signing Secretを設定した場合はSlackのスラッシュコマンドとEvents APIを受け付ける
+// -scheduleを指定した場合は設定ファイルのschedule.jobsを定期的に実行する（HTTPで提供するものがなければジョブの実行だけを行う）
+//
+//	GET  /api/wordcloud                    ワードクラウドデータ（-input）
+//	GET  /api/words/{word}/messages        単語が出現したメッセージのサンプル（-input）
+//	POST /slack/commands                   スラッシュコマンド（serve.signing_secret）
+//	POST /slack/events                     Events API（serve.signing_secret）
+//	POST /api/jobs                         チャンネルのワードクラウドを作成する非同期ジョブ（-jobs）
+//	GET  /api/jobs/{id}[/events]           ジョブの状態と進捗（-jobs）
+func runServe(args []string) {
+	fs, s := newCommand("serve", "[flags]", args)
+	input := fs.String("input", "", "Input CSV file path")
+	scheduled := fs.Bool("schedule", false, "Run the jobs in schedule.jobs of the config file on their cron expressions")
+	jobs := fs.Bool("jobs", false, "Enable the asynchronous job API (POST /api/jobs; requires serve.job_token or -job-channels)")
+	fs.Var(&s.Serve.JobChannels, "job-channels", "Comma-separated channel IDs the job API accepts")
+	token := registerSlack(fs, &s.Slack)
+	fs.StringVar(&s.Schedule.History, "history", s.Schedule.History, "Job history file (JSON Lines)")
+	fs.StringVar(&s.Schedule.LockDir, "lock-dir", s.Schedule.LockDir, "Directory for lock files shared by instances running the same jobs")
+	fs.IntVar(&s.Schedule.Retries, "retries", s.Schedule.Retries, "Number of retries for a failed job")
+	fs.Var(&s.Schedule.RetryDelay, "retry-delay", "Delay before the first retry (doubled on each retry)")
+	registerAnalyze(fs, &s.Analyze)
+	registerRender(fs, &s.Render)
+	registerTimezone(fs, s)
+	fs.StringVar(&s.Serve.Addr, "addr", s.Serve.Addr, "HTTP listen address")
+	fs.Parse(args)
+
+	if *token != "" {
+		s.Slack.Token = *token
+	}
+	if *input == "" && !*scheduled && !*jobs && s.Serve.SigningSecret == "" {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	done := make(chan error, 1)
+	if *scheduled {
+		scheduler := newScheduler(s)
+		go func() { done <- scheduler.Run(ctx) }()
+	}
+
+	if *input == "" && !*jobs && s.Serve.SigningSecret == "" {
+		if err := <-done; err != nil {
+			log.Fatalf("ジョブの実行に失敗: %v", err)
+		}
+		return
+	}
+
+	mux := http.NewServeMux()
+	if *input != "" {
+		generator, index, wordCounts := generateIndex(s, *input)
+		mux.Handle("/api/", server.New(generator, index, wordCounts))
+	}
+	if *jobs {
+		jobManager := newJobManager(s)
+		defer jobManager.Close()
+		mux.Handle("/api/jobs", jobManager)
+		mux.Handle("/api/jobs/", jobManager)
+	}
+	if s.Serve.SigningSecret != "" {
+		slackHandler := newSlackHandler(s)
+		defer slackHandler.Close()
+		mux.Handle("/slack/", slackHandler)
+	}
+	srv := &http.Server{Addr: s.Serve.Addr, Handler: mux}
+	served := make(chan error, 1)
+	go func() { served <- srv.ListenAndServe() }()
+	log.Printf("サーバーを起動します: %s", s.Serve.Addr)
+
+	// シグナルを受け取るか、サーバーかスケジューラーのどちらかが止まったら全体を停止する
+	var scheduleErr error
+	scheduleDone := false
+	select {
+	case <-ctx.Done():
+	case err := <-served:
+		if !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("サーバーの起動に失敗: %v", err)
+		}
+	case scheduleErr = <-done:
+		scheduleDone = true
+		log.Printf("エラー: ジョブの定期実行が停止したためサーバーを停止します: %v", scheduleErr)
+		stop()
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	srv.Shutdown(shutdownCtx)
+
+	if *scheduled && !scheduleDone {
+		// 実行中のジョブの終了を待つ
+		scheduleErr = <-done
+	}
+	if scheduleErr != nil {
+		log.Fatalf("ジョブの実行に失敗: %v", scheduleErr)
+	}
+}
+
+// newSlackHandler はスラッシュコマンドとEvents APIを受け付けるハンドラーを作成
+func newSlackHandler(s *settings.Settings) *server.SlackHandler {
+	if s.Slack.Token == "" {
+		log.Fatal("Error: スラッシュコマンドの処理にはトークンが必要です（SLACK_TOKEN または設定ファイルの slack.token）")
+	}
+	location, err := s.Location()
+	if err != nil {
+		log.Fatal(err)
+	}
+	return server.NewSlackHandler(s.Serve.SigningSecret, newClient(s), newPipeline(s), location,
+		server.WithSlackLogger(slog.Default()),
+	)
+}
+
+// newJobManager は非同期ジョブのAPIを提供するJobManagerを作成
+func newJobManager(s *settings.Settings) *server.JobManager {
+	if s.Slack.Token == "" {
+		log.Fatal("Error: ジョブの実行にはトークンが必要です（SLACK_TOKEN または設定ファイルの slack.token）")
+	}
+	location, err := s.Location()
+	if err != nil {
+		log.Fatal(err)
+	}
+	options := []server.JobOption{
+		server.WithJobChannels(s.Serve.JobChannels...),
+		server.WithJobLogger(slog.Default()),
+	}
+	if s.Serve.JobToken != "" {
+		options = append(options, server.WithJobToken(s.Serve.JobToken))
+	}
+	manager, err := server.NewJobManager(newPipeline(s), location, options...)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return manager
+}
+
+// newScheduler は設定のジョブを実行するSchedulerを作成
+func newScheduler(s *settings.Settings) *schedule.Scheduler {
+	if s.Slack.Token == "" {
+		log.Fatal("Error: ジョブの実行にはトークンが必要です（SLACK_TOKEN または設定ファイルの slack.token）")
+	}
+	location, err := s.Location()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	scheduler, err := schedule.New(newPipeline(s), s.Schedule.Jobs,
+		schedule.WithLocation(location),
+		schedule.WithHistory(s.Schedule.History),
+		schedule.WithLock(s.Schedule.LockDir),
+		schedule.WithRetry(s.Schedule.Retries, time.Duration(s.Schedule.RetryDelay)),
+		schedule.WithLogger(slog.Default()),
+	)
+	if err != nil {
+		log.Fatalf("ジョブの設定が不正です: %v", err)
+	}
+	return scheduler
+}
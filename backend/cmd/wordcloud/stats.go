@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Tattsum/wordcloud/backend/pkg/wordcloud"
+)
+
+// runStats はCSVファイルのメッセージ数・ユーザー数・期間と出現回数の多い単語を表示する
+//
+//	wordcloud stats -input messages.csv -top 20
+func runStats(args []string) {
+	fs, s := newCommand("stats", "[flags]", args)
+	input := fs.String("input", "", "Input CSV file path")
+	top := fs.Int("top", 20, "Number of top words to list")
+	registerAnalyze(fs, &s.Analyze)
+	registerTimezone(fs, s)
+	fs.Parse(args)
+
+	if *input == "" {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	// 最小出現回数に達したすべての単語を集計する
+	processor := newProcessor(s, func(c *wordcloud.Config) { c.MaxWords = math.MaxInt32 })
+	progress := wordcloud.WithProgress(newLogProgress())
+	messages, err := processor.ReadCSV(*input, messageColumn, progress)
+	if err != nil {
+		log.Fatalf("CSVファイルの読み込みに失敗: %v", err)
+	}
+	wordCounts, err := processor.Generator().GenerateMessages(messages, progress)
+	if err != nil && !wordcloud.IsEmptyError(err) {
+		log.Fatalf("単語の集計に失敗: %v", err)
+	}
+
+	location, err := s.Location()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	users := make(map[string]bool)
+	threads := make(map[string]bool)
+	var first, last time.Time
+	for _, msg := range messages {
+		users[msg.UserID] = true
+		threads[msg.ThreadKey()] = true
+		if msg.Timestamp.IsZero() {
+			continue
+		}
+		if first.IsZero() || msg.Timestamp.Before(first) {
+			first = msg.Timestamp
+		}
+		if msg.Timestamp.After(last) {
+			last = msg.Timestamp
+		}
+	}
+	total := 0
+	for _, wc := range wordCounts {
+		total += wc.Count
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "メッセージ数\t%d\n", len(messages))
+	fmt.Fprintf(w, "ユーザー数\t%d\n", len(users))
+	fmt.Fprintf(w, "スレッド数\t%d\n", len(threads))
+	if !first.IsZero() {
+		fmt.Fprintf(w, "期間\t%s 〜 %s\n", first.In(location).Format(time.DateTime), last.In(location).Format(time.DateTime))
+	}
+	fmt.Fprintf(w, "単語の種類（%d回以上）\t%d\n", s.Analyze.MinCount, len(wordCounts))
+	fmt.Fprintf(w, "単語の出現回数\t%d\n", total)
+	w.Flush()
+
+	if len(wordCounts) > 0 && *top > 0 {
+		fmt.Println()
+		w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+		for i, wc := range wordCounts[:min(*top, len(wordCounts))] {
+			fmt.Fprintf(w, "%d\t%s\t%d\t%.1f%%\t\n", i+1, wc.Text, wc.Count, float64(wc.Count)/float64(total)*100)
+		}
+		w.Flush()
+	}
+}
@@ -3,16 +3,19 @@ module github.com/Tattsum/wordcloud/backend
 go 1.23.5
 
 require (
+	github.com/BurntSushi/toml v1.5.0
 	github.com/fogleman/gg v1.3.0
 	github.com/golang/freetype v0.0.0-20170609003504-e2365dfdc4a0
 	github.com/ikawaha/kagome-dict/ipa v1.2.0
 	github.com/ikawaha/kagome/v2 v2.10.0
 	github.com/slack-go/slack v0.15.0
+	golang.org/x/image v0.23.0
 	golang.org/x/sync v0.10.0
+	golang.org/x/text v0.21.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
 	github.com/gorilla/websocket v1.4.2 // indirect
 	github.com/ikawaha/kagome-dict v1.1.0 // indirect
-	golang.org/x/image v0.23.0 // indirect
 )
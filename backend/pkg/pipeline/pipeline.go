@@ -0,0 +1,407 @@
+// Package pipeline はSlackチャンネルのメッセージ取得からワードクラウドの出力までを一度に実行する
+package pipeline
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Tattsum/wordcloud/backend/pkg/slack"
+	"github.com/Tattsum/wordcloud/backend/pkg/wordcloud"
+)
+
+// Request は1回の実行で対象とするチャンネル・期間と出力先
+type Request struct {
+	Channel string    // チャンネルID
+	Oldest  time.Time // この日時以降のメッセージを対象にする（ゼロ値は制限なし）
+	Latest  time.Time // この日時より前のメッセージを対象にする（ゼロ値は現在まで）
+	Outputs []string  // 出力するファイル（拡張子が .png/.svg/.json のいずれか）
+	Post    string    // 最初のPNGをまとめとともに投稿するチャンネルID（空の場合は投稿しない）
+	Thread  string    // 投稿をスレッドへの返信にする場合のスレッドのタイムスタンプ
+
+	Progress wordcloud.Progress // メッセージの取得・解析・出力の進捗の通知先（nilの場合は通知しない）
+}
+
+// StageExport はファイルの出力の段階（出力済みのファイル数を通知する）
+const StageExport = "export"
+
+// Result は実行結果
+type Result struct {
+	Messages []wordcloud.Message   // 集計したメッセージ（スレッドの返信を含む）
+	Words    []wordcloud.WordCount // ワードクラウドデータ（単語がない場合は空）
+	Outputs  []string              // 出力したファイル
+	Cached   bool                  // メッセージをキャッシュから読み込んだ
+	Posted   *slack.UploadedFile   // 投稿したファイル（投稿しなかった場合はnil）
+}
+
+// Pipeline はSlackクライアントとFileProcessorを組み合わせてワードクラウドを作成する
+type Pipeline struct {
+	client    *slack.Client
+	processor *wordcloud.FileProcessor
+	cacheDir  string
+	cacheTTL  time.Duration
+	location  *time.Location
+	now       func() time.Time
+	logger    *slog.Logger
+}
+
+// Option はPipelineの設定オプション関数の型
+type Option func(*Pipeline)
+
+// WithCache は取得したメッセージをdirにJSONで保存し、次回以降の同じ期間の実行で再利用するオプション
+// 期間の終わりが保存時より前であれば常に再利用し、そうでなければ保存からttl以内の場合だけ再利用する
+// 期間の端はttlの区切りにそろえて保存するため、7dなどの相対指定でもttl以内の実行では同じキャッシュを使う
+func WithCache(dir string, ttl time.Duration) Option {
+	return func(p *Pipeline) {
+		p.cacheDir = dir
+		p.cacheTTL = ttl
+	}
+}
+
+// WithLocation は投稿するまとめの期間を表示するタイムゾーンを指定するオプション
+func WithLocation(loc *time.Location) Option {
+	return func(p *Pipeline) {
+		p.location = loc
+	}
+}
+
+// WithLogger はログの出力先を設定するオプション（指定しない場合はログを出力しない）
+func WithLogger(logger *slog.Logger) Option {
+	return func(p *Pipeline) {
+		if logger != nil {
+			p.logger = logger
+		}
+	}
+}
+
+// discardLogger はロガーを指定しない場合に使う、何も出力しないロガー
+var discardLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// New は新しいPipelineを作成
+func New(client *slack.Client, processor *wordcloud.FileProcessor, options ...Option) *Pipeline {
+	p := &Pipeline{
+		client:    client,
+		processor: processor,
+		location:  time.Local,
+		now:       time.Now,
+		logger:    discardLogger,
+	}
+	for _, opt := range options {
+		opt(p)
+	}
+	return p
+}
+
+// Run はメッセージを取得して集計し、Request.Outputsのファイルに出力する
+// 単語がない場合は画像にメッセージだけを表示し、JSONには空の配列を出力する
+// Request.Postを指定した場合は最初のPNGをまとめのメッセージとともにチャンネルに投稿する
+func (p *Pipeline) Run(req Request) (*Result, error) {
+	start := time.Now()
+	image := ""
+	for _, output := range req.Outputs {
+		format, err := outputFormat(output)
+		if err != nil {
+			return nil, err
+		}
+		if format == formatPNG && image == "" {
+			image = output
+		}
+	}
+	if req.Post != "" && image == "" {
+		return nil, fmt.Errorf("投稿するにはPNGの出力先を指定してください")
+	}
+
+	messages, cached, err := p.Messages(req.Channel, req.Oldest, req.Latest, slack.WithProgress(req.Progress))
+	if err != nil {
+		return nil, err
+	}
+
+	words, err := p.processor.ProcessMessages(messages, wordcloud.WithProgress(req.Progress))
+	if wordcloud.IsEmptyError(err) {
+		p.logger.Warn("表示できる単語がありません", "channel", req.Channel, "messages", len(messages), "error", err)
+		words, err = []wordcloud.WordCount{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ワードクラウドデータの生成に失敗: %w", err)
+	}
+
+	result := &Result{Messages: messages, Words: words, Cached: cached}
+	for i, output := range req.Outputs {
+		if err := p.export(words, output); err != nil {
+			return result, err
+		}
+		result.Outputs = append(result.Outputs, output)
+		if req.Progress != nil {
+			req.Progress.Report(StageExport, i+1, len(req.Outputs))
+		}
+	}
+
+	if req.Post != "" {
+		// 期間の終わりを指定していない場合は、まとめに実行した日時までと表示する
+		period := req
+		if period.Latest.IsZero() {
+			period.Latest = p.now()
+		}
+		posted, err := p.client.UploadFile(req.Post, image,
+			slack.WithComment(Summary(result, period, p.location)),
+			slack.WithThread(req.Thread),
+		)
+		if err != nil {
+			return result, fmt.Errorf("ワードクラウドの投稿に失敗: %w", err)
+		}
+		result.Posted = posted
+	}
+	p.logger.Info("ワードクラウドを作成しました", "channel", req.Channel, "messages", len(messages), "words", len(words), "cached", cached, "duration", time.Since(start))
+	return result, nil
+}
+
+// 出力形式
+const (
+	formatPNG  = ".png"
+	formatSVG  = ".svg"
+	formatJSON = ".json"
+)
+
+// outputFormat は出力ファイルの拡張子から形式を判定
+func outputFormat(path string) (string, error) {
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case formatPNG, formatSVG, formatJSON:
+		return ext, nil
+	default:
+		return "", fmt.Errorf("出力形式を拡張子から判定できません（.png/.svg/.json）: %s", path)
+	}
+}
+
+// export はワードクラウドデータを拡張子に応じた形式で出力
+func (p *Pipeline) export(words []wordcloud.WordCount, path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("出力ディレクトリの作成に失敗: %w", err)
+	}
+
+	format, _ := outputFormat(path)
+	switch format {
+	case formatPNG:
+		return p.processor.ExportPNG(words, path)
+	case formatSVG:
+		return p.processor.ExportSVG(words, path)
+	default:
+		return p.processor.ExportJSON(words, path)
+	}
+}
+
+// Messages はチャンネルの期間内のメッセージをスレッドの返信も含めて返す
+// キャッシュが有効な場合は保存したメッセージを使い、2つ目の戻り値がtrueになる
+// optionsはSlackからメッセージを取得する場合に追加で指定するオプション
+func (p *Pipeline) Messages(channel string, oldest, latest time.Time, options ...slack.MessageOption) ([]wordcloud.Message, bool, error) {
+	if channel == "" {
+		return nil, false, fmt.Errorf("チャンネルIDが指定されていません")
+	}
+
+	// 相対指定（7dなど）の期間は実行のたびに端が変わるため、キャッシュを使う場合は
+	// 端を区切りの良い日時に広げた範囲で取得・保存し、集計には指定された期間のメッセージだけを使う
+	fetchOldest, fetchLatest := p.cacheRange(oldest, latest)
+	cachePath := p.cachePath(channel, fetchOldest, fetchLatest)
+	if raw, ok := p.readCache(cachePath, fetchLatest); ok {
+		p.logger.Info("キャッシュからメッセージを読み込みました", "channel", channel, "path", cachePath, "messages", len(raw))
+		return convertMessages(filterRange(raw, oldest, latest)), true, nil
+	}
+
+	raw, err := p.client.GetChannelMessages(channel, append([]slack.MessageOption{slack.WithTimeRange(fetchOldest, fetchLatest)}, options...)...)
+	if err != nil {
+		return nil, false, fmt.Errorf("チャンネル %s: %w", channel, err)
+	}
+	if cachePath != "" {
+		// キャッシュの保存・削除に失敗しても集計は続ける
+		if err := writeCache(cachePath, raw); err != nil {
+			p.logger.Warn("キャッシュを保存できません", "channel", channel, "path", cachePath, "error", err)
+		}
+		if err := p.pruneCache(); err != nil {
+			p.logger.Warn("古いキャッシュを削除できません", "dir", p.cacheDir, "error", err)
+		}
+	}
+	return convertMessages(filterRange(raw, oldest, latest)), false, nil
+}
+
+// cacheRange はキャッシュに保存する期間を返す
+// 期間の開始はキャッシュの有効期間（未指定の場合は1時間）の区切りに切り下げ、終了は切り上げる
+func (p *Pipeline) cacheRange(oldest, latest time.Time) (time.Time, time.Time) {
+	if p.cacheDir == "" {
+		return oldest, latest
+	}
+	step := p.cacheTTL
+	if step <= 0 {
+		step = time.Hour
+	}
+	if !oldest.IsZero() {
+		oldest = oldest.Truncate(step)
+	}
+	if !latest.IsZero() {
+		if t := latest.Truncate(step); t.Before(latest) {
+			latest = t.Add(step)
+		}
+	}
+	return oldest, latest
+}
+
+// filterRange は[oldest, latest)に投稿されたメッセージとスレッドの返信だけを返す
+// 投稿日時を解釈できないメッセージは残す
+func filterRange(raw []slack.SlackMessage, oldest, latest time.Time) []slack.SlackMessage {
+	in := func(ts string) bool {
+		t := wordcloud.ParseTimestamp(ts, time.UTC)
+		if t.IsZero() {
+			return true
+		}
+		return (oldest.IsZero() || !t.Before(oldest)) && (latest.IsZero() || t.Before(latest))
+	}
+
+	var filtered []slack.SlackMessage
+	for _, msg := range raw {
+		if !in(msg.Timestamp) {
+			continue
+		}
+		replies := msg.Replies
+		msg.Replies = nil
+		for _, reply := range replies {
+			if in(reply.Timestamp) {
+				msg.Replies = append(msg.Replies, reply)
+			}
+		}
+		filtered = append(filtered, msg)
+	}
+	return filtered
+}
+
+// cachePath はチャンネルと期間に対応するキャッシュファイルのパスを返す（キャッシュが無効な場合は空）
+func (p *Pipeline) cachePath(channel string, oldest, latest time.Time) string {
+	if p.cacheDir == "" {
+		return ""
+	}
+	stamp := func(t time.Time, empty string) string {
+		if t.IsZero() {
+			return empty
+		}
+		return t.UTC().Format(cacheStampLayout)
+	}
+	name := fmt.Sprintf("%s_%s_%s%s", channel, stamp(oldest, "start"), stamp(latest, "now"), cacheExt)
+	return filepath.Join(p.cacheDir, name)
+}
+
+// キャッシュファイルの名前の形式
+const (
+	cacheExt         = ".json"
+	cacheStampLayout = "20060102T150405Z"
+)
+
+// cacheRetention は期間の終わりより後に保存した（内容が変わらない）キャッシュを残す期間
+const cacheRetention = 30 * 24 * time.Hour
+
+// pruneCache は再利用されなくなったキャッシュファイルを削除する
+// 期間が現在を含むキャッシュは有効期間を過ぎたもの、それ以外は保存からcacheRetentionを過ぎたものを削除する
+func (p *Pipeline) pruneCache() error {
+	entries, err := os.ReadDir(p.cacheDir)
+	if err != nil {
+		return fmt.Errorf("キャッシュディレクトリの読み込みに失敗: %w", err)
+	}
+
+	now := p.now()
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, cacheExt) {
+			continue
+		}
+		parts := strings.Split(strings.TrimSuffix(name, cacheExt), "_")
+		if len(parts) != 3 {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+
+		age := now.Sub(info.ModTime())
+		latest, err := time.Parse(cacheStampLayout, parts[2])
+		closed := err == nil && info.ModTime().After(latest)
+		if (!closed && age > p.cacheTTL) || age > cacheRetention {
+			if err := os.Remove(filepath.Join(p.cacheDir, name)); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("古いキャッシュの削除に失敗: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// readCache は有効なキャッシュがあれば保存したメッセージを返す
+func (p *Pipeline) readCache(path string, latest time.Time) ([]slack.SlackMessage, bool) {
+	if path == "" {
+		return nil, false
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, false
+	}
+
+	// 期間の終わりより後に保存したキャッシュは内容が変わらないため常に使う
+	closed := !latest.IsZero() && info.ModTime().After(latest)
+	if !closed && p.now().Sub(info.ModTime()) > p.cacheTTL {
+		return nil, false
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	var raw []slack.SlackMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		p.logger.Warn("キャッシュを読み込めないため再取得します", "path", path, "error", err)
+		return nil, false
+	}
+	return raw, true
+}
+
+// writeCache は取得したメッセージをキャッシュファイルに保存する
+func writeCache(path string, raw []slack.SlackMessage) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("キャッシュディレクトリの作成に失敗: %w", err)
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return fmt.Errorf("キャッシュの作成に失敗: %w", err)
+	}
+
+	// 書き込み途中のファイルを読まないように一時ファイルから置き換える
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("キャッシュの保存に失敗: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("キャッシュの保存に失敗: %w", err)
+	}
+	return nil
+}
+
+// convertMessages はSlackのメッセージをスレッドの返信も含めて集計用のメッセージに変換
+func convertMessages(raw []slack.SlackMessage) []wordcloud.Message {
+	var messages []wordcloud.Message
+	var add func(msgs []slack.SlackMessage)
+	add = func(msgs []slack.SlackMessage) {
+		for _, msg := range msgs {
+			messages = append(messages, wordcloud.Message{
+				ID:        msg.ID,
+				Row:       len(messages) + 1,
+				Text:      msg.Text,
+				UserID:    msg.UserID,
+				Username:  msg.Username,
+				Timestamp: wordcloud.ParseTimestamp(msg.Timestamp, time.UTC),
+				ThreadTS:  msg.ThreadTS,
+			})
+			add(msg.Replies)
+		}
+	}
+	add(raw)
+	return messages
+}
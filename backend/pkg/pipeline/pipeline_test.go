@@ -0,0 +1,142 @@
+package pipeline
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Tattsum/wordcloud/backend/pkg/slack"
+	"github.com/Tattsum/wordcloud/backend/pkg/wordcloud"
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+// fakeSlack はメッセージの取得とファイルのアップロードに使うAPIを模したSlackのサーバー
+type fakeSlack struct {
+	server *httptest.Server
+
+	mu       sync.Mutex
+	file     []byte     // アップロードURLに送信されたファイル
+	complete url.Values // files.completeUploadExternalのパラメーター
+}
+
+func newFakeSlack(t *testing.T, messages []map[string]string) *fakeSlack {
+	t.Helper()
+	f := &fakeSlack{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/conversations.history", func(w http.ResponseWriter, r *http.Request) {
+		writeFakeJSON(w, map[string]any{"ok": true, "messages": messages, "has_more": false})
+	})
+	mux.HandleFunc("/api/files.getUploadURLExternal", func(w http.ResponseWriter, r *http.Request) {
+		writeFakeJSON(w, map[string]any{"ok": true, "upload_url": f.server.URL + "/upload/F0001", "file_id": "F0001"})
+	})
+	mux.HandleFunc("/upload/F0001", func(w http.ResponseWriter, r *http.Request) {
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			t.Errorf("アップロードされたファイルを読み込めない: %v", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+		data, _ := io.ReadAll(file)
+		f.mu.Lock()
+		f.file = data
+		f.mu.Unlock()
+	})
+	mux.HandleFunc("/api/files.completeUploadExternal", func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("files.completeUploadExternalのパラメーターを解析できない: %v", err)
+		}
+		f.mu.Lock()
+		f.complete = r.PostForm
+		f.mu.Unlock()
+		writeFakeJSON(w, map[string]any{"ok": true, "files": []map[string]string{{"id": "F0001", "title": "wordcloud.png"}}})
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("想定外のAPI呼び出し: %s", r.URL.Path)
+		http.NotFound(w, r)
+	})
+	f.server = httptest.NewServer(mux)
+	t.Cleanup(f.server.Close)
+	return f
+}
+
+func writeFakeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func TestRunPost(t *testing.T) {
+	fake := newFakeSlack(t, []map[string]string{
+		{"type": "message", "user": "U0000000001", "text": "障害の対応を開始します", "ts": "1767232800.000100"},
+		{"type": "message", "user": "U0000000002", "text": "障害の原因を調査しています", "ts": "1767236400.000100"},
+		{"type": "message", "user": "U0000000001", "text": "障害の対応が完了しました", "ts": "1767240000.000100"},
+	})
+
+	fontPath := filepath.Join(t.TempDir(), "Go-Regular.ttf")
+	if err := os.WriteFile(fontPath, goregular.TTF, 0644); err != nil {
+		t.Fatalf("フォントの書き込みに失敗: %v", err)
+	}
+	analyzer, err := wordcloud.NewAnalyzer()
+	if err != nil {
+		t.Fatalf("アナライザーの初期化に失敗: %v", err)
+	}
+	processor, err := wordcloud.NewFileProcessor(wordcloud.Config{
+		MinCount:    1,
+		MaxWords:    20,
+		MinFontSize: 12,
+		MaxFontSize: 32,
+		ColorScheme: "blue",
+		Width:       200,
+		Height:      150,
+		FontPath:    fontPath,
+	}, analyzer)
+	if err != nil {
+		t.Fatalf("プロセッサーの初期化に失敗: %v", err)
+	}
+	client := slack.NewClient(slack.ClientConfig{Token: "xoxb-test", RateLimit: time.Millisecond, APIURL: fake.server.URL + "/api/"})
+	p := New(client, processor, WithLocation(time.UTC))
+
+	output := filepath.Join(t.TempDir(), "wordcloud.png")
+	req := Request{
+		Channel: "C0123456789",
+		Oldest:  time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+		Latest:  time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC),
+		Outputs: []string{output},
+		Post:    "C0123456789",
+		Thread:  "1767225600.000100",
+	}
+	result, err := p.Run(req)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if result.Posted == nil || result.Posted.ID != "F0001" {
+		t.Errorf("投稿したファイル = %+v", result.Posted)
+	}
+
+	image, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	if !bytes.Equal(fake.file, image) {
+		t.Errorf("アップロードされたファイルが出力したPNG画像と異なる（%d バイト、期待値 %d バイト）", len(fake.file), len(image))
+	}
+	for key, want := range map[string]string{
+		"channel_id":      req.Post,
+		"initial_comment": Summary(result, req, time.UTC),
+		"thread_ts":       req.Thread,
+	} {
+		if got := fake.complete.Get(key); got != want {
+			t.Errorf("%s = %q, want %q", key, got, want)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package pipeline
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// summaryTopWords は投稿するまとめに載せる上位の単語数
+const summaryTopWords = 10
+
+// summaryLayout はまとめの期間の表示形式
+const summaryLayout = "2006-01-02 15:04"
+
+// Summary は投稿に添えるまとめ（期間・メッセージ数・上位の単語）を返す
+// 期間の指定がない側は集計したメッセージの最初・最後の日時を表示する
+func Summary(result *Result, req Request, loc *time.Location) string {
+	oldest, latest := req.Oldest, req.Latest
+	for _, msg := range result.Messages {
+		if msg.Timestamp.IsZero() {
+			continue
+		}
+		if req.Oldest.IsZero() && (oldest.IsZero() || msg.Timestamp.Before(oldest)) {
+			oldest = msg.Timestamp
+		}
+		if req.Latest.IsZero() && msg.Timestamp.After(latest) {
+			latest = msg.Timestamp
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("*ワードクラウド*")
+	if !oldest.IsZero() && !latest.IsZero() {
+		fmt.Fprintf(&b, " %s 〜 %s", oldest.In(loc).Format(summaryLayout), latest.In(loc).Format(summaryLayout))
+	}
+	fmt.Fprintf(&b, "\nメッセージ数: %d", len(result.Messages))
+
+	if len(result.Words) == 0 {
+		b.WriteString("\n表示できる単語がありませんでした")
+		return b.String()
+	}
+	top := make([]string, 0, summaryTopWords)
+	for _, word := range result.Words[:min(summaryTopWords, len(result.Words))] {
+		top = append(top, fmt.Sprintf("%s (%d)", word.Text, word.Count))
+	}
+	fmt.Fprintf(&b, "\n上位の単語: %s", strings.Join(top, "、"))
+	return b.String()
+}
@@ -0,0 +1,73 @@
+package pipeline
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Tattsum/wordcloud/backend/pkg/wordcloud"
+)
+
+func TestSummary(t *testing.T) {
+	jst := time.FixedZone("JST", 9*60*60)
+	oldest := time.Date(2026, 1, 1, 0, 0, 0, 0, jst)
+	latest := time.Date(2026, 1, 8, 0, 0, 0, 0, jst)
+	messages := []wordcloud.Message{
+		{Text: "障害対応", Timestamp: time.Date(2026, 1, 2, 1, 0, 0, 0, time.UTC)},
+		{Text: "リリース", Timestamp: time.Date(2026, 1, 5, 3, 30, 0, 0, time.UTC)},
+		{Text: "日時なし"},
+	}
+	words := []wordcloud.WordCount{{Text: "障害", Count: 5}, {Text: "リリース", Count: 3}}
+
+	var many []wordcloud.WordCount
+	for i := range summaryTopWords + 2 {
+		many = append(many, wordcloud.WordCount{Text: fmt.Sprintf("単語%d", i), Count: 20 - i})
+	}
+
+	tests := []struct {
+		name   string
+		result *Result
+		req    Request
+		want   string
+	}{
+		{
+			name:   "期間を指定",
+			result: &Result{Messages: messages, Words: words},
+			req:    Request{Oldest: oldest, Latest: latest},
+			want:   "*ワードクラウド* 2026-01-01 00:00 〜 2026-01-08 00:00\nメッセージ数: 3\n上位の単語: 障害 (5)、リリース (3)",
+		},
+		{
+			name:   "期間の指定がない側はメッセージの日時",
+			result: &Result{Messages: messages, Words: words},
+			req:    Request{Latest: latest},
+			want:   "*ワードクラウド* 2026-01-02 10:00 〜 2026-01-08 00:00\nメッセージ数: 3\n上位の単語: 障害 (5)、リリース (3)",
+		},
+		{
+			name:   "期間もメッセージの日時もない",
+			result: &Result{Messages: messages[2:], Words: words[:1]},
+			req:    Request{},
+			want:   "*ワードクラウド*\nメッセージ数: 1\n上位の単語: 障害 (5)",
+		},
+		{
+			name:   "単語がない",
+			result: &Result{Messages: messages[:1]},
+			req:    Request{Oldest: oldest, Latest: latest},
+			want:   "*ワードクラウド* 2026-01-01 00:00 〜 2026-01-08 00:00\nメッセージ数: 1\n表示できる単語がありませんでした",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Summary(tt.result, tt.req, jst); got != tt.want {
+				t.Errorf("Summary() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("上位の単語数", func(t *testing.T) {
+		got := Summary(&Result{Words: many}, Request{}, jst)
+		if !strings.Contains(got, "単語9 (11)") || strings.Contains(got, "単語10") {
+			t.Errorf("上位%d件に絞られていない: %q", summaryTopWords, got)
+		}
+	})
+}
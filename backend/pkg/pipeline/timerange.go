@@ -0,0 +1,80 @@
+package pipeline
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// rangeLayouts は期間の指定に受け付ける日時の形式
+var rangeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02T15:04",
+}
+
+// dateLayout は日付だけの指定の形式
+const dateLayout = "2006-01-02"
+
+// ParseRange はfrom・toの文字列から期間[oldest, latest)を返す
+// 日付（2026-01-31）、日時（RFC3339など）、nowから遡る相対指定（7d・12h・2w）を受け付ける
+// toに日付を指定した場合はその日の終わりまでを含み、空の場合はゼロ値（制限なし）を返す
+func ParseRange(from, to string, now time.Time, loc *time.Location) (time.Time, time.Time, error) {
+	oldest, err := parseTime(from, now, loc, false)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("期間の開始が不正です: %w", err)
+	}
+	latest, err := parseTime(to, now, loc, true)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("期間の終了が不正です: %w", err)
+	}
+	if !oldest.IsZero() && !latest.IsZero() && !oldest.Before(latest) {
+		return time.Time{}, time.Time{}, fmt.Errorf("期間の開始が終了より後です: %s〜%s", from, to)
+	}
+	return oldest, latest, nil
+}
+
+// parseTime は期間の端の文字列を解析（endOfDayがtrueの場合、日付はその翌日の0時とする）
+func parseTime(value string, now time.Time, loc *time.Location, endOfDay bool) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if d, ok := parseRelative(value); ok {
+		return now.Add(-d), nil
+	}
+	if t, err := time.ParseInLocation(dateLayout, value, loc); err == nil {
+		if endOfDay {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t, nil
+	}
+	for _, layout := range rangeLayouts {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("日付・日時・相対指定（7dなど）のいずれかを指定してください: %q", value)
+}
+
+// parseRelative は "7d"・"2w"・"12h" の形式の相対指定を解析
+func parseRelative(value string) (time.Duration, bool) {
+	if len(value) < 2 {
+		return 0, false
+	}
+	n, err := strconv.Atoi(value[:len(value)-1])
+	if err != nil || n < 0 {
+		return 0, false
+	}
+	switch value[len(value)-1] {
+	case 'h':
+		return time.Duration(n) * time.Hour, true
+	case 'd':
+		return time.Duration(n) * 24 * time.Hour, true
+	case 'w':
+		return time.Duration(n) * 7 * 24 * time.Hour, true
+	}
+	return 0, false
+}
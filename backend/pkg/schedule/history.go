@@ -0,0 +1,122 @@
+package schedule
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 実行結果の状態
+const (
+	StatusSuccess = "success" // 成功
+	StatusFailed  = "failed"  // 再試行しても失敗
+	StatusSkipped = "skipped" // 他のインスタンスが実行済み・実行中
+)
+
+// Record はジョブの実行履歴の1件（チャンネルごと）
+type Record struct {
+	Job       string    `json:"job"`
+	Channel   string    `json:"channel,omitempty"`
+	Scheduled time.Time `json:"scheduled"`          // 実行予定の日時
+	Started   time.Time `json:"started"`            // 実行を開始した日時
+	Finished  time.Time `json:"finished"`           // 実行を終了した日時
+	Status    string    `json:"status"`             // success・failed・skipped
+	Attempts  int       `json:"attempts,omitempty"` // 試行回数
+	Messages  int       `json:"messages,omitempty"` // 集計したメッセージ数
+	Outputs   []string  `json:"outputs,omitempty"`  // 出力したファイル
+	Error     string    `json:"error,omitempty"`    // 最後に発生したエラー
+}
+
+// history は実行履歴をJSON Lines形式のファイルに追記する
+type history struct {
+	path  string
+	mutex sync.Mutex
+}
+
+// append は実行履歴を1行追記する（パスが空の場合は何もしない）
+func (h *history) append(record Record) error {
+	if h.path == "" {
+		return nil
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("実行履歴の作成に失敗: %w", err)
+	}
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(h.path), 0755); err != nil {
+		return fmt.Errorf("実行履歴のディレクトリの作成に失敗: %w", err)
+	}
+	file, err := os.OpenFile(h.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("実行履歴のファイルを開けません: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("実行履歴の書き込みに失敗: %w", err)
+	}
+	return nil
+}
+
+// lockRetention はロックファイルを残しておく期間
+const lockRetention = 7 * 24 * time.Hour
+
+// errLocked は他のインスタンスがロックを取得済みのエラー
+var errLocked = errors.New("他のインスタンスが実行済みまたは実行中です")
+
+// locker はジョブの実行予定ごとのロックファイルで、複数のインスタンスが同じ実行を重複して行わないようにする
+// ロックファイルは実行後も残し、同じ実行予定を再び実行しないようにする
+type locker struct {
+	dir string
+}
+
+// acquire はジョブの実行予定のロックを取得する（ディレクトリが空の場合は常に取得できる）
+func (l *locker) acquire(job string, scheduled time.Time) error {
+	if l.dir == "" {
+		return nil
+	}
+	if err := os.MkdirAll(l.dir, 0755); err != nil {
+		return fmt.Errorf("ロックディレクトリの作成に失敗: %w", err)
+	}
+
+	name := fmt.Sprintf("%s_%s.lock", job, scheduled.UTC().Format("20060102T1504Z"))
+	file, err := os.OpenFile(filepath.Join(l.dir, name), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if errors.Is(err, os.ErrExist) {
+		return errLocked
+	}
+	if err != nil {
+		return fmt.Errorf("ロックの取得に失敗: %w", err)
+	}
+	defer file.Close()
+
+	hostname, _ := os.Hostname()
+	fmt.Fprintf(file, "host=%s pid=%d\n", hostname, os.Getpid())
+	return nil
+}
+
+// cleanup は保持期間を過ぎたロックファイルを削除する
+func (l *locker) cleanup(now time.Time) {
+	if l.dir == "" {
+		return
+	}
+	entries, err := os.ReadDir(l.dir)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		if !strings.HasSuffix(entry.Name(), ".lock") {
+			continue
+		}
+		if info, err := entry.Info(); err == nil && now.Sub(info.ModTime()) > lockRetention {
+			os.Remove(filepath.Join(l.dir, entry.Name()))
+		}
+	}
+}
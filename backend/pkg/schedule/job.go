@@ -0,0 +1,86 @@
+package schedule
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Tattsum/wordcloud/backend/pkg/pipeline"
+)
+
+// Job は定期的に実行するワードクラウドの作成
+type Job struct {
+	Name      string   `json:"name"`       // ジョブ名（履歴・ロック・出力ファイル名に使う）
+	Cron      string   `json:"cron"`       // 実行日時のcron式（例: "0 9 * * mon"）
+	Channels  []string `json:"channels"`   // 対象のチャンネルID（チャンネルごとに作成する）
+	Period    string   `json:"period"`     // 実行日時から遡る対象期間（7d・24h・2wなど）
+	Formats   []string `json:"formats"`    // 出力形式（png・svg・json、既定はpng）
+	OutputDir string   `json:"output_dir"` // 出力先ディレクトリ（既定はdata）
+	Post      string   `json:"post"`       // PNGをまとめとともに投稿するチャンネルID（空の場合は投稿しない）
+}
+
+// defaultJobOutputDir はジョブの出力先ディレクトリの既定値
+const defaultJobOutputDir = "data"
+
+// validate はジョブの設定を検証し、解析したcron式を返す
+func (j Job) validate() (*Cron, error) {
+	if j.Name == "" || strings.ContainsAny(j.Name, `/\ `) {
+		return nil, fmt.Errorf("ジョブ名は空白や区切り文字を含まない文字列で指定してください: %q", j.Name)
+	}
+	cron, err := ParseCron(j.Cron)
+	if err != nil {
+		return nil, fmt.Errorf("ジョブ %s: %w", j.Name, err)
+	}
+	if len(j.Channels) == 0 {
+		return nil, fmt.Errorf("ジョブ %s: チャンネルが指定されていません", j.Name)
+	}
+	if j.Period == "" {
+		return nil, fmt.Errorf("ジョブ %s: 対象期間（period）が指定されていません", j.Name)
+	}
+	if _, _, err := pipeline.ParseRange(j.Period, "", time.Now(), time.UTC); err != nil {
+		return nil, fmt.Errorf("ジョブ %s: %w", j.Name, err)
+	}
+	for _, format := range j.Formats {
+		if !slices.Contains([]string{"png", "svg", "json"}, strings.ToLower(format)) {
+			return nil, fmt.Errorf("ジョブ %s: 出力形式はpng・svg・jsonのいずれかを指定してください: %s", j.Name, format)
+		}
+	}
+	return cron, nil
+}
+
+// request はチャンネルごとに実行するパイプラインのリクエストを返す
+// 出力ファイル名は「ジョブ名_チャンネルID_実行日時.拡張子」とする
+func (j Job) request(channel string, at time.Time, loc *time.Location) (pipeline.Request, error) {
+	oldest, latest, err := pipeline.ParseRange(j.Period, "", at, loc)
+	if err != nil {
+		return pipeline.Request{}, err
+	}
+	// 再試行で実行が遅れても対象期間が変わらないように、期間の終わりを実行予定の日時に固定する
+	if latest.IsZero() {
+		latest = at
+	}
+
+	var formats []string
+	for _, format := range j.Formats {
+		formats = append(formats, strings.ToLower(format))
+	}
+	if len(formats) == 0 {
+		formats = []string{"png"}
+	}
+	if j.Post != "" && !slices.Contains(formats, "png") {
+		formats = append(formats, "png")
+	}
+	dir := j.OutputDir
+	if dir == "" {
+		dir = defaultJobOutputDir
+	}
+
+	req := pipeline.Request{Channel: channel, Oldest: oldest, Latest: latest, Post: j.Post}
+	for _, format := range formats {
+		name := fmt.Sprintf("%s_%s_%s.%s", j.Name, channel, at.In(loc).Format("20060102_1504"), format)
+		req.Outputs = append(req.Outputs, filepath.Join(dir, name))
+	}
+	return req, nil
+}
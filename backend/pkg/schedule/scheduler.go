@@ -0,0 +1,247 @@
+// Package schedule はcron式に従ってワードクラウドの作成を定期的に実行する
+package schedule
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/Tattsum/wordcloud/backend/pkg/pipeline"
+)
+
+// Scheduler はジョブをcron式の日時に実行し、失敗した場合は再試行する
+type Scheduler struct {
+	pipeline   *pipeline.Pipeline
+	jobs       []Job
+	crons      []*Cron
+	location   *time.Location
+	history    *history
+	locker     *locker
+	retries    int
+	retryDelay time.Duration
+	now        func() time.Time
+	logger     *slog.Logger
+
+	mutex   sync.Mutex
+	running map[string]bool // 実行中のジョブ名
+}
+
+// Option はSchedulerの設定オプション関数の型
+type Option func(*Scheduler)
+
+// WithLocation はcron式と出力ファイル名の日時を判定するタイムゾーンを指定するオプション
+func WithLocation(loc *time.Location) Option {
+	return func(s *Scheduler) {
+		s.location = loc
+	}
+}
+
+// WithHistory は実行履歴をpathにJSON Lines形式で追記するオプション
+func WithHistory(path string) Option {
+	return func(s *Scheduler) {
+		s.history.path = path
+	}
+}
+
+// WithLock はdirのロックファイルで、同じディレクトリを使う他のインスタンスと実行が重複しないようにするオプション
+func WithLock(dir string) Option {
+	return func(s *Scheduler) {
+		s.locker.dir = dir
+	}
+}
+
+// WithRetry は失敗したチャンネルを最大retries回再試行するオプション
+// 再試行の間隔はdelayから1回ごとに2倍にする
+func WithRetry(retries int, delay time.Duration) Option {
+	return func(s *Scheduler) {
+		s.retries = retries
+		s.retryDelay = delay
+	}
+}
+
+// WithLogger はログの出力先を設定するオプション（指定しない場合はログを出力しない）
+func WithLogger(logger *slog.Logger) Option {
+	return func(s *Scheduler) {
+		if logger != nil {
+			s.logger = logger
+		}
+	}
+}
+
+// discardLogger はロガーを指定しない場合に使う、何も出力しないロガー
+var discardLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// New はジョブを検証して新しいSchedulerを作成
+func New(p *pipeline.Pipeline, jobs []Job, options ...Option) (*Scheduler, error) {
+	s := &Scheduler{
+		pipeline:   p,
+		jobs:       jobs,
+		location:   time.Local,
+		history:    &history{},
+		locker:     &locker{},
+		retryDelay: time.Minute,
+		now:        time.Now,
+		logger:     discardLogger,
+		running:    make(map[string]bool),
+	}
+	for _, opt := range options {
+		opt(s)
+	}
+
+	if len(jobs) == 0 {
+		return nil, fmt.Errorf("実行するジョブがありません")
+	}
+	names := make(map[string]bool)
+	for _, job := range jobs {
+		cron, err := job.validate()
+		if err != nil {
+			return nil, err
+		}
+		if names[job.Name] {
+			return nil, fmt.Errorf("ジョブ名が重複しています: %s", job.Name)
+		}
+		names[job.Name] = true
+		s.crons = append(s.crons, cron)
+	}
+	return s, nil
+}
+
+// Run はctxがキャンセルされるまでジョブを実行日時ごとに実行する
+// 実行中のジョブがある場合は終了を待ってから戻る
+func (s *Scheduler) Run(ctx context.Context) error {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	next := make([]time.Time, len(s.jobs))
+	for i, cron := range s.crons {
+		next[i] = cron.Next(s.now().In(s.location))
+		s.logger.Info("ジョブの次回の実行日時を決めました", "job", s.jobs[i].Name, "next", next[i].Format(time.DateTime))
+	}
+
+	for {
+		earliest := time.Time{}
+		for _, t := range next {
+			if !t.IsZero() && (earliest.IsZero() || t.Before(earliest)) {
+				earliest = t
+			}
+		}
+		if earliest.IsZero() {
+			return fmt.Errorf("実行日時が見つかるジョブがありません")
+		}
+
+		timer := time.NewTimer(earliest.Sub(s.now()))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		case <-timer.C:
+		}
+
+		s.locker.cleanup(s.now())
+		for i, t := range next {
+			if t.IsZero() || t.After(earliest) {
+				continue
+			}
+			job, scheduled := s.jobs[i], t
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				s.RunJob(ctx, job, scheduled)
+			}()
+			next[i] = s.crons[i].Next(t)
+		}
+	}
+}
+
+// RunJob はジョブをscheduledの実行予定としてチャンネルごとに実行し、実行履歴を返す
+// 同じジョブを実行中の場合や他のインスタンスがロックを取得済みの場合は実行しない
+func (s *Scheduler) RunJob(ctx context.Context, job Job, scheduled time.Time) []Record {
+	if !s.start(job.Name) {
+		s.logger.Warn("前回の実行が終わっていないためジョブをスキップします", "job", job.Name)
+		return []Record{s.record(Record{Job: job.Name, Scheduled: scheduled, Started: s.now(), Finished: s.now(),
+			Status: StatusSkipped, Error: "前回の実行が終わっていません"})}
+	}
+	defer s.finish(job.Name)
+
+	if err := s.locker.acquire(job.Name, scheduled); err != nil {
+		s.logger.Info("ジョブをスキップします", "job", job.Name, "reason", err)
+		return []Record{s.record(Record{Job: job.Name, Scheduled: scheduled, Started: s.now(), Finished: s.now(),
+			Status: StatusSkipped, Error: err.Error()})}
+	}
+
+	s.logger.Info("ジョブを実行します", "job", job.Name, "channels", len(job.Channels))
+	var records []Record
+	for _, channel := range job.Channels {
+		records = append(records, s.record(s.runChannel(ctx, job, channel, scheduled)))
+	}
+	return records
+}
+
+// runChannel はチャンネルのワードクラウドを作成し、失敗した場合は再試行する
+func (s *Scheduler) runChannel(ctx context.Context, job Job, channel string, scheduled time.Time) Record {
+	record := Record{Job: job.Name, Channel: channel, Scheduled: scheduled, Started: s.now()}
+
+	req, err := job.request(channel, scheduled, s.location)
+	if err != nil {
+		record.Status, record.Error, record.Finished = StatusFailed, err.Error(), s.now()
+		return record
+	}
+
+	delay := s.retryDelay
+	for attempt := 0; attempt <= s.retries; attempt++ {
+		if attempt > 0 {
+			s.logger.Warn("ジョブを再試行します", "job", job.Name, "channel", channel, "delay", delay, "attempt", attempt, "retries", s.retries, "error", record.Error)
+			select {
+			case <-ctx.Done():
+				record.Status, record.Finished = StatusFailed, s.now()
+				return record
+			case <-time.After(delay):
+			}
+			delay *= 2
+		}
+
+		record.Attempts = attempt + 1
+		result, err := s.pipeline.Run(req)
+		if err == nil {
+			record.Status, record.Error = StatusSuccess, ""
+			record.Messages, record.Outputs = len(result.Messages), result.Outputs
+			record.Finished = s.now()
+			s.logger.Info("ジョブが完了しました", "job", job.Name, "channel", channel, "messages", record.Messages, "attempts", record.Attempts, "duration", record.Finished.Sub(record.Started))
+			return record
+		}
+		record.Error = err.Error()
+	}
+
+	record.Status, record.Finished = StatusFailed, s.now()
+	s.logger.Error("ジョブが失敗しました", "job", job.Name, "channel", channel, "attempts", record.Attempts, "duration", record.Finished.Sub(record.Started), "error", record.Error)
+	return record
+}
+
+// record は実行履歴を追記する
+func (s *Scheduler) record(record Record) Record {
+	if err := s.history.append(record); err != nil {
+		s.logger.Warn("実行履歴を追記できません", "job", record.Job, "error", err)
+	}
+	return record
+}
+
+// start はジョブを実行中にする（既に実行中の場合はfalseを返す）
+func (s *Scheduler) start(name string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.running[name] {
+		return false
+	}
+	s.running[name] = true
+	return true
+}
+
+// finish はジョブの実行中を解除する
+func (s *Scheduler) finish(name string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.running, name)
+}
@@ -0,0 +1,426 @@
+package server
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Tattsum/wordcloud/backend/pkg/pipeline"
+	"github.com/Tattsum/wordcloud/backend/pkg/wordcloud"
+)
+
+// 非同期ジョブの状態
+const (
+	JobQueued    = "queued"    // 実行待ち
+	JobRunning   = "running"   // 実行中
+	JobSucceeded = "succeeded" // 成功
+	JobFailed    = "failed"    // 失敗
+)
+
+// jobQueueSize は実行待ちにできるジョブの数
+const jobQueueSize = 16
+
+// maxJobs は状態を保持するジョブの最大数（超えた場合は古い終了済みのジョブから削除する）
+const maxJobs = 100
+
+// jobFormats はジョブで出力できる形式
+var jobFormats = []string{"png", "svg", "json"}
+
+// JobStatus は非同期ジョブの状態
+type JobStatus struct {
+	ID       string                `json:"id"`
+	State    string                `json:"state"`              // queued・running・succeeded・failed
+	Channel  string                `json:"channel"`            // 対象のチャンネルID
+	Stage    string                `json:"stage,omitempty"`    // 実行中の段階（fetch_messages・analyze・exportなど）
+	Done     int                   `json:"done"`               // 段階の処理済みの件数
+	Total    int                   `json:"total"`              // 段階の総数（0の場合は不明）
+	Created  time.Time             `json:"created"`            // ジョブを受け付けた日時
+	Started  *time.Time            `json:"started,omitempty"`  // 実行を開始した日時
+	Finished *time.Time            `json:"finished,omitempty"` // 実行を終了した日時
+	Messages int                   `json:"messages,omitempty"` // 集計したメッセージ数
+	Files    map[string]string     `json:"files,omitempty"`    // 出力形式ごとのダウンロードURL
+	Words    []wordcloud.WordCount `json:"words,omitempty"`    // ワードクラウドデータ（成功した場合）
+	Error    string                `json:"error,omitempty"`    // 失敗した理由
+}
+
+// finished はジョブが終了したかを判定
+func (s JobStatus) finished() bool {
+	return s.State == JobSucceeded || s.State == JobFailed
+}
+
+// jobRequest はジョブの作成リクエスト
+type jobRequest struct {
+	Channel string   `json:"channel"` // チャンネルID
+	From    string   `json:"from"`    // 期間の開始（日付・日時・7dなどの相対指定、既定は7d）
+	To      string   `json:"to"`      // 期間の終了（空の場合は現在まで）
+	Formats []string `json:"formats"` // 出力形式（png・svg・json、既定はpngとjson）
+}
+
+// job は実行中・実行済みのジョブ
+type job struct {
+	status  JobStatus
+	request pipeline.Request
+	files   map[string]string // 出力形式ごとのファイルのパス
+	changed chan struct{}     // 状態が変わると閉じて作り直す
+}
+
+// JobManager はワードクラウドの作成を非同期のジョブとして受け付け、進捗を提供する
+// ジョブは受け付けた順に1件ずつ実行する
+// トークンを設定した場合はすべてのリクエストに Authorization: Bearer <トークン> を求め、
+// チャンネルを設定した場合はそれ以外のチャンネルのジョブを受け付けない
+//
+//	POST /api/jobs                       ジョブの作成（{"channel": "C...", "from": "30d"}）
+//	GET  /api/jobs/{id}                  ジョブの状態
+//	GET  /api/jobs/{id}/events           ジョブの進捗（Server-Sent Events）
+//	GET  /api/jobs/{id}/files/{format}   出力したファイル
+type JobManager struct {
+	pipeline *pipeline.Pipeline
+	location *time.Location
+	token    string   // リクエストに求めるBearerトークン（空の場合は求めない）
+	channels []string // ジョブを受け付けるチャンネル（空の場合は制限しない）
+	dir      string
+	mux      *http.ServeMux
+	queue    chan *job
+	wg       sync.WaitGroup
+	now      func() time.Time
+	logger   *slog.Logger
+
+	mutex sync.Mutex
+	jobs  map[string]*job
+	order []string // 受け付けた順のジョブID
+}
+
+// JobOption はJobManagerの設定オプション関数の型
+type JobOption func(*JobManager)
+
+// WithJobToken はリクエストにBearerトークンを求めるオプション
+func WithJobToken(token string) JobOption {
+	return func(m *JobManager) {
+		m.token = token
+	}
+}
+
+// WithJobChannels はジョブを受け付けるチャンネルを制限するオプション
+func WithJobChannels(channels ...string) JobOption {
+	return func(m *JobManager) {
+		m.channels = channels
+	}
+}
+
+// WithJobLogger はログの出力先を設定するオプション（指定しない場合はログを出力しない）
+func WithJobLogger(logger *slog.Logger) JobOption {
+	return func(m *JobManager) {
+		if logger != nil {
+			m.logger = logger
+		}
+	}
+}
+
+// NewJobManager は出力を一時ディレクトリに保存するJobManagerを作成し、ジョブを実行するワーカーを開始する
+// locは期間に日付を指定した場合のタイムゾーン
+// 誰でもBotのトークンでチャンネルを読めてしまわないよう、トークンかチャンネルのどちらかの指定を必須とする
+func NewJobManager(p *pipeline.Pipeline, loc *time.Location, options ...JobOption) (*JobManager, error) {
+	m := &JobManager{
+		pipeline: p,
+		location: loc,
+		mux:      http.NewServeMux(),
+		queue:    make(chan *job, jobQueueSize),
+		now:      time.Now,
+		logger:   discardLogger,
+		jobs:     make(map[string]*job),
+	}
+	for _, opt := range options {
+		opt(m)
+	}
+	if m.token == "" && len(m.channels) == 0 {
+		return nil, fmt.Errorf("ジョブのAPIにはトークンか受け付けるチャンネルの指定が必要です")
+	}
+
+	dir, err := os.MkdirTemp("", "wordcloud-jobs-")
+	if err != nil {
+		return nil, fmt.Errorf("ジョブの出力ディレクトリの作成に失敗: %w", err)
+	}
+
+	m.dir = dir
+	m.mux.HandleFunc("POST /api/jobs", m.handleCreate)
+	m.mux.HandleFunc("GET /api/jobs/{id}", m.handleStatus)
+	m.mux.HandleFunc("GET /api/jobs/{id}/events", m.handleEvents)
+	m.mux.HandleFunc("GET /api/jobs/{id}/files/{format}", m.handleFile)
+
+	m.wg.Add(1)
+	go m.work()
+	return m, nil
+}
+
+// ServeHTTP はトークンを検証してリクエストを各ハンドラーに振り分ける
+func (m *JobManager) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if m.token != "" {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(m.token)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeError(w, http.StatusUnauthorized, "トークンを検証できません")
+			return
+		}
+	}
+	m.mux.ServeHTTP(w, r)
+}
+
+// Close は新しいジョブの受け付けを終了し、実行待ちのジョブが終わるのを待って出力を削除する
+func (m *JobManager) Close() {
+	close(m.queue)
+	m.wg.Wait()
+	os.RemoveAll(m.dir)
+}
+
+// handleCreate はジョブを作成して実行待ちに追加する
+func (m *JobManager) handleCreate(w http.ResponseWriter, r *http.Request) {
+	var body jobRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "リクエストを解析できません")
+		return
+	}
+
+	if channel := strings.TrimSpace(body.Channel); len(m.channels) > 0 && channel != "" && !slices.Contains(m.channels, channel) {
+		writeError(w, http.StatusForbidden, "このチャンネルのジョブは受け付けていません: "+channel)
+		return
+	}
+	j, err := m.newJob(body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	m.mutex.Lock()
+	select {
+	case m.queue <- j:
+		m.jobs[j.status.ID] = j
+		m.order = append(m.order, j.status.ID)
+		m.prune()
+	default:
+		m.mutex.Unlock()
+		writeError(w, http.StatusServiceUnavailable, "実行待ちのジョブが多いため受け付けられません")
+		return
+	}
+	status := j.status
+	m.mutex.Unlock()
+
+	w.Header().Set("Location", "/api/jobs/"+status.ID)
+	writeJSON(w, http.StatusAccepted, status)
+}
+
+// newJob はリクエストを検証してジョブを作成する
+func (m *JobManager) newJob(body jobRequest) (*job, error) {
+	channel := strings.TrimSpace(body.Channel)
+	if channel == "" {
+		return nil, fmt.Errorf("channel を指定してください")
+	}
+	from := body.From
+	if from == "" {
+		from = defaultSlackPeriod
+	}
+	oldest, latest, err := pipeline.ParseRange(from, body.To, m.now(), m.location)
+	if err != nil {
+		return nil, err
+	}
+
+	formats := body.Formats
+	if len(formats) == 0 {
+		formats = []string{"png", "json"}
+	}
+	id, err := newJobID()
+	if err != nil {
+		return nil, err
+	}
+
+	j := &job{
+		status:  JobStatus{ID: id, State: JobQueued, Channel: channel, Created: m.now()},
+		request: pipeline.Request{Channel: channel, Oldest: oldest, Latest: latest},
+		files:   make(map[string]string),
+		changed: make(chan struct{}),
+	}
+	for _, format := range formats {
+		format = strings.ToLower(format)
+		if !slices.Contains(jobFormats, format) {
+			return nil, fmt.Errorf("formats にはpng・svg・jsonを指定してください: %s", format)
+		}
+		if _, ok := j.files[format]; ok {
+			continue
+		}
+		path := filepath.Join(m.dir, id, "wordcloud."+format)
+		j.files[format] = path
+		j.request.Outputs = append(j.request.Outputs, path)
+	}
+	return j, nil
+}
+
+// newJobID はランダムなジョブIDを返す
+func newJobID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("ジョブIDの作成に失敗: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// prune は保持するジョブが多すぎる場合に古い終了済みのジョブと出力を削除する（mutexを取得して呼び出す）
+func (m *JobManager) prune() {
+	for i := 0; len(m.order) > maxJobs && i < len(m.order); {
+		id := m.order[i]
+		if !m.jobs[id].status.finished() {
+			i++
+			continue
+		}
+		delete(m.jobs, id)
+		m.order = slices.Delete(m.order, i, i+1)
+		os.RemoveAll(filepath.Join(m.dir, id))
+	}
+}
+
+// update はジョブの状態を更新し、状態の変化を待っているリクエストに知らせる
+func (m *JobManager) update(j *job, modify func(*JobStatus)) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	modify(&j.status)
+	close(j.changed)
+	j.changed = make(chan struct{})
+}
+
+// work は実行待ちのジョブを順に実行する
+func (m *JobManager) work() {
+	defer m.wg.Done()
+	for j := range m.queue {
+		m.run(j)
+	}
+}
+
+// run はジョブを実行し、進捗と結果を状態に反映する
+func (m *JobManager) run(j *job) {
+	started := m.now()
+	m.update(j, func(s *JobStatus) {
+		s.State, s.Started = JobRunning, &started
+	})
+
+	req := j.request
+	req.Progress = wordcloud.ProgressFunc(func(stage string, done, total int) {
+		m.update(j, func(s *JobStatus) {
+			s.Stage, s.Done, s.Total = stage, done, total
+		})
+	})
+	result, err := m.pipeline.Run(req)
+
+	m.update(j, func(s *JobStatus) {
+		now := m.now()
+		s.Finished = &now
+		if err != nil {
+			s.State, s.Error = JobFailed, err.Error()
+			return
+		}
+		s.State, s.Messages, s.Words = JobSucceeded, len(result.Messages), result.Words
+		s.Files = make(map[string]string, len(j.files))
+		for format := range j.files {
+			s.Files[format] = fmt.Sprintf("/api/jobs/%s/files/%s", s.ID, format)
+		}
+	})
+	if err != nil {
+		m.logger.Error("ジョブが失敗しました", "job", j.status.ID, "channel", req.Channel, "duration", m.now().Sub(started), "error", err)
+		return
+	}
+	m.logger.Info("ジョブが完了しました", "job", j.status.ID, "channel", req.Channel, "messages", len(result.Messages), "duration", m.now().Sub(started))
+}
+
+// snapshot はジョブの状態と、次に状態が変わると閉じるチャネルを返す
+func (m *JobManager) snapshot(id string) (JobStatus, <-chan struct{}, bool) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	j, ok := m.jobs[id]
+	if !ok {
+		return JobStatus{}, nil, false
+	}
+	return j.status, j.changed, true
+}
+
+// handleStatus はジョブの状態を返す
+func (m *JobManager) handleStatus(w http.ResponseWriter, r *http.Request) {
+	status, _, ok := m.snapshot(r.PathValue("id"))
+	if !ok {
+		writeError(w, http.StatusNotFound, "ジョブが見つかりません")
+		return
+	}
+	writeJSON(w, http.StatusOK, status)
+}
+
+// handleEvents はジョブの状態が変わるたびにServer-Sent Eventsで送る
+// 実行中は progress イベント、終了すると done イベントを送って接続を閉じる
+func (m *JobManager) handleEvents(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if _, _, ok := m.snapshot(id); !ok {
+		writeError(w, http.StatusNotFound, "ジョブが見つかりません")
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "ストリーミングに対応していません")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	for {
+		status, changed, ok := m.snapshot(id)
+		if !ok {
+			return
+		}
+		event := "progress"
+		if status.finished() {
+			event = "done"
+		}
+		// 単語のデータは量が多いため、終了後にジョブの状態から取得してもらう
+		status.Words = nil
+		data, err := json.Marshal(status)
+		if err != nil {
+			m.logger.Error("イベントの作成に失敗しました", "job", id, "error", err)
+			return
+		}
+		fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data)
+		flusher.Flush()
+
+		if status.finished() {
+			return
+		}
+		select {
+		case <-changed:
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// handleFile はジョブが出力したファイルを返す
+func (m *JobManager) handleFile(w http.ResponseWriter, r *http.Request) {
+	m.mutex.Lock()
+	j, ok := m.jobs[r.PathValue("id")]
+	var path string
+	if ok && j.status.State == JobSucceeded {
+		path = j.files[r.PathValue("format")]
+	}
+	m.mutex.Unlock()
+
+	if path == "" {
+		writeError(w, http.StatusNotFound, "ファイルが見つかりません")
+		return
+	}
+	http.ServeFile(w, r, path)
+}
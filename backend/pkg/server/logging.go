@@ -0,0 +1,9 @@
+package server
+
+import (
+	"io"
+	"log/slog"
+)
+
+// discardLogger はロガーを指定しない場合に使う、何も出力しないロガー
+var discardLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
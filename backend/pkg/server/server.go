@@ -0,0 +1,113 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/Tattsum/wordcloud/backend/pkg/wordcloud"
+)
+
+// defaultSampleLimit はサンプルメッセージの既定の件数
+const defaultSampleLimit = 10
+
+// defaultContextWidth はKWICの前後の文脈の既定の文字数
+const defaultContextWidth = 20
+
+// Server はワードクラウドのデータをHTTPで提供するサーバー
+type Server struct {
+	generator *wordcloud.Generator
+	index     *wordcloud.WordIndex
+	words     []wordcloud.WordCount
+	mux       *http.ServeMux
+}
+
+// New は生成済みのワードクラウドデータを提供するサーバーを作成
+// 単語ごとのメッセージはGenerator.BuildIndexで作成したindexから返す（nilの場合は返さない）
+func New(generator *wordcloud.Generator, index *wordcloud.WordIndex, words []wordcloud.WordCount) *Server {
+	s := &Server{
+		generator: generator,
+		index:     index,
+		words:     words,
+		mux:       http.NewServeMux(),
+	}
+	s.mux.HandleFunc("GET /api/wordcloud", s.handleWordCloud)
+	s.mux.HandleFunc("GET /api/words/{word}/messages", s.handleWordMessages)
+	return s
+}
+
+// ServeHTTP はリクエストを各ハンドラーに振り分ける
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// handleWordCloud はワードクラウドデータを返す
+func (s *Server) handleWordCloud(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.words)
+}
+
+// wordMessagesResponse は単語ごとのサンプルメッセージのレスポンス
+type wordMessagesResponse struct {
+	Word     string                 `json:"word"`     // 検索語を索引のキーに変換した単語
+	Total    int                    `json:"total"`    // 単語が出現したメッセージ数
+	Messages []wordcloud.MessageRef `json:"messages"` // 出現回数の多い順のサンプルメッセージ
+	Lines    []wordcloud.KWICLine   `json:"lines"`    // 入力の順序で最大limit件の出現箇所
+}
+
+// handleWordMessages は単語が出現したメッセージのサンプルを返す
+// クエリパラメーター limit で件数、context でKWICの前後の文字数を指定できる
+func (s *Server) handleWordMessages(w http.ResponseWriter, r *http.Request) {
+	index := s.index
+	if index == nil {
+		writeError(w, http.StatusServiceUnavailable, "単語の索引が作成されていません")
+		return
+	}
+
+	limit, err := queryInt(r, "limit", defaultSampleLimit)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "limit には整数を指定してください")
+		return
+	}
+	width, err := queryInt(r, "context", defaultContextWidth)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "context には整数を指定してください")
+		return
+	}
+
+	word := s.generator.ResolveWord(r.PathValue("word"))
+	refs := index.Lookup(word)
+	if len(refs) == 0 {
+		writeError(w, http.StatusNotFound, "単語が見つかりません: "+word)
+		return
+	}
+
+	resp := wordMessagesResponse{
+		Word:     word,
+		Total:    len(refs),
+		Messages: index.Sample(word, limit),
+		Lines:    index.Concordance(word, width, limit),
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// queryInt はクエリパラメーターを整数として取得（指定がない場合はdefaultValue）
+func queryInt(r *http.Request, name string, defaultValue int) (int, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return defaultValue, nil
+	}
+	return strconv.Atoi(value)
+}
+
+// writeJSON は値をJSONレスポンスとして書き込む
+func writeJSON(w http.ResponseWriter, status int, data any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	// ステータスを送信した後は応答を変えられず、失敗するのはクライアントが切断した場合なのでエラーは無視する
+	json.NewEncoder(w).Encode(data)
+}
+
+// writeError はエラーメッセージをJSONレスポンスとして書き込む
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
@@ -0,0 +1,340 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Tattsum/wordcloud/backend/pkg/pipeline"
+	"github.com/Tattsum/wordcloud/backend/pkg/slack"
+)
+
+// defaultSlackPeriod は期間を省略した場合に対象とする期間
+const defaultSlackPeriod = "7d"
+
+// slackQueueSize は実行待ちにできるリクエストの数
+const slackQueueSize = 8
+
+// errNotMember はリクエストしたユーザーが対象のチャンネルのメンバーでない場合のエラー
+var errNotMember = errors.New("メンバーではないチャンネルのワードクラウドは作成できません")
+
+// errPrivateChannel は非公開チャンネルのワードクラウドをほかのチャンネルに投稿しようとした場合のエラー
+var errPrivateChannel = errors.New("非公開チャンネルのワードクラウドはそのチャンネルでのみ作成できます")
+
+// slackUsage はコマンドの使い方
+const slackUsage = "使い方: `/wordcloud [#チャンネル] [期間]`（例: `/wordcloud #general 30d`）\n" +
+	"チャンネルを省略するとコマンドを実行したチャンネル、期間を省略すると直近7日間を対象にします。"
+
+// SlackHandler はSlackのスラッシュコマンドとEvents APIのリクエストを受け付け、ワードクラウドを作成して投稿する
+// リクエストには署名を検証してすぐに応答し、ワードクラウドの作成は1件ずつ順に行う
+// ほかのチャンネルを対象にできるのは、公開チャンネルでリクエストしたユーザーがそのチャンネルのメンバーの場合だけ
+//
+//	POST /slack/commands    スラッシュコマンド（/wordcloud #channel 30d）
+//	POST /slack/events      Events API（URLの検証とBotへのメンション）
+type SlackHandler struct {
+	secret   string
+	client   *slack.Client
+	pipeline *pipeline.Pipeline
+	location *time.Location
+	mux      *http.ServeMux
+	queue    chan slackRequest
+	wg       sync.WaitGroup
+	now      func() time.Time
+	logger   *slog.Logger
+}
+
+// SlackOption はSlackHandlerの設定オプション関数の型
+type SlackOption func(*SlackHandler)
+
+// WithSlackLogger はログの出力先を設定するオプション（指定しない場合はログを出力しない）
+func WithSlackLogger(logger *slog.Logger) SlackOption {
+	return func(h *SlackHandler) {
+		if logger != nil {
+			h.logger = logger
+		}
+	}
+}
+
+// slackRequest はワードクラウドの作成を待つリクエスト
+type slackRequest struct {
+	channel     string // 対象のチャンネルID
+	period      string // 対象期間
+	user        string // リクエストしたユーザーのID
+	post        string // 投稿先のチャンネルID
+	thread      string // 投稿先のスレッド（メンションの場合）
+	responseURL string // 結果を知らせるURL（スラッシュコマンドの場合）
+}
+
+// NewSlackHandler は新しいSlackHandlerを作成し、ワードクラウドを作成するワーカーを開始する
+// locは期間に日付を指定した場合のタイムゾーン
+func NewSlackHandler(secret string, client *slack.Client, p *pipeline.Pipeline, loc *time.Location, options ...SlackOption) *SlackHandler {
+	h := newSlackHandler(secret, client, p, loc, options...)
+	h.wg.Add(1)
+	go h.work()
+	return h
+}
+
+// newSlackHandler はワーカーを開始せずにSlackHandlerを作成する
+func newSlackHandler(secret string, client *slack.Client, p *pipeline.Pipeline, loc *time.Location, options ...SlackOption) *SlackHandler {
+	h := &SlackHandler{
+		secret:   secret,
+		client:   client,
+		pipeline: p,
+		location: loc,
+		mux:      http.NewServeMux(),
+		queue:    make(chan slackRequest, slackQueueSize),
+		now:      time.Now,
+		logger:   discardLogger,
+	}
+	for _, opt := range options {
+		opt(h)
+	}
+	h.mux.HandleFunc("POST /slack/commands", h.handleCommand)
+	h.mux.HandleFunc("POST /slack/events", h.handleEvent)
+	return h
+}
+
+// ServeHTTP はリクエストの署名を検証して各ハンドラーに振り分ける
+func (h *SlackHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if _, err := slack.VerifyRequest(r, h.secret, h.now()); err != nil {
+		h.logger.Warn("Slackリクエストを拒否しました", "path", r.URL.Path, "error", err)
+		writeError(w, http.StatusUnauthorized, "署名を検証できません")
+		return
+	}
+	h.mux.ServeHTTP(w, r)
+}
+
+// Close は新しいリクエストの受け付けを終了し、実行待ちのリクエストの処理が終わるまで待つ
+func (h *SlackHandler) Close() {
+	close(h.queue)
+	h.wg.Wait()
+}
+
+// handleCommand はスラッシュコマンドを受け付ける
+// Slackは3秒以内の応答を求めるため、実行待ちに追加した時点でコマンドを実行したユーザーに応答する
+func (h *SlackHandler) handleCommand(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeError(w, http.StatusBadRequest, "リクエストを解析できません")
+		return
+	}
+
+	text := strings.TrimSpace(r.PostForm.Get("text"))
+	if text == "help" {
+		writeSlackResponse(w, slackUsage)
+		return
+	}
+	channel, period, err := parseSlackCommand(text, r.PostForm.Get("channel_id"), h.now(), h.location)
+	if err != nil {
+		writeSlackResponse(w, fmt.Sprintf("%v\n%s", err, slackUsage))
+		return
+	}
+
+	req := slackRequest{
+		channel:     channel,
+		period:      period,
+		user:        r.PostForm.Get("user_id"),
+		post:        r.PostForm.Get("channel_id"),
+		responseURL: r.PostForm.Get("response_url"),
+	}
+	if !h.enqueue(req) {
+		writeSlackResponse(w, "ほかのワードクラウドを作成中のため受け付けられませんでした。しばらくしてから再度お試しください。")
+		return
+	}
+	writeSlackResponse(w, fmt.Sprintf("<#%s> の直近 %s のワードクラウドを作成しています…", channel, period))
+}
+
+// slackEvent はEvents APIのリクエストのうち使用する項目
+type slackEvent struct {
+	Type      string `json:"type"`
+	Challenge string `json:"challenge"`
+	Event     struct {
+		Type     string `json:"type"`
+		Text     string `json:"text"`
+		User     string `json:"user"`
+		Channel  string `json:"channel"`
+		TS       string `json:"ts"`
+		ThreadTS string `json:"thread_ts"`
+		BotID    string `json:"bot_id"`
+	} `json:"event"`
+}
+
+// handleEvent はEvents APIのリクエストを受け付ける
+// URLの検証に応答し、Botへのメンション（@wordcloud #channel 30d）はスラッシュコマンドと同様に扱ってスレッドに投稿する
+func (h *SlackHandler) handleEvent(w http.ResponseWriter, r *http.Request) {
+	var event slackEvent
+	if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+		writeError(w, http.StatusBadRequest, "リクエストを解析できません")
+		return
+	}
+
+	switch event.Type {
+	case "url_verification":
+		writeJSON(w, http.StatusOK, map[string]string{"challenge": event.Challenge})
+		return
+	case "event_callback":
+	default:
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	// 応答が遅れた場合の再送は、最初のリクエストで受け付け済みのため無視する
+	w.WriteHeader(http.StatusOK)
+	if r.Header.Get("X-Slack-Retry-Num") != "" || event.Event.Type != "app_mention" || event.Event.BotID != "" {
+		return
+	}
+
+	thread := event.Event.ThreadTS
+	if thread == "" {
+		thread = event.Event.TS
+	}
+	text := strings.TrimSpace(mentionPattern.ReplaceAllString(event.Event.Text, ""))
+	channel, period, err := parseSlackCommand(text, event.Event.Channel, h.now(), h.location)
+	if err != nil {
+		h.reply(slackRequest{post: event.Event.Channel, thread: thread}, fmt.Sprintf("%v\n%s", err, slackUsage))
+		return
+	}
+	req := slackRequest{channel: channel, period: period, user: event.Event.User, post: event.Event.Channel, thread: thread}
+	if !h.enqueue(req) {
+		h.reply(req, "ほかのワードクラウドを作成中のため受け付けられませんでした。しばらくしてから再度お試しください。")
+	}
+}
+
+// mentionPattern はメッセージ中のユーザーへのメンション
+var mentionPattern = regexp.MustCompile(`<@[A-Z0-9]+(\|[^>]*)?>`)
+
+// channelPattern はエスケープされたチャンネルの指定（<#C1234567890|general>）
+var channelPattern = regexp.MustCompile(`^<#([A-Z0-9]+)(\|[^>]*)?>$`)
+
+// channelIDPattern はチャンネルIDをそのまま指定した場合の形式
+var channelIDPattern = regexp.MustCompile(`^[CG][A-Z0-9]{8,}$`)
+
+// parseSlackCommand はコマンドの引数から対象のチャンネルIDと期間を返す
+// チャンネルを省略した場合はcurrentを、期間を省略した場合は直近7日間を対象にする
+func parseSlackCommand(text, current string, now time.Time, loc *time.Location) (string, string, error) {
+	channel, period := current, defaultSlackPeriod
+	for _, arg := range strings.Fields(text) {
+		switch {
+		case channelPattern.MatchString(arg):
+			channel = channelPattern.FindStringSubmatch(arg)[1]
+		case channelIDPattern.MatchString(arg):
+			channel = arg
+		case strings.HasPrefix(arg, "#"):
+			return "", "", fmt.Errorf("チャンネル %s をIDに変換できません。候補から選択して指定してください", arg)
+		default:
+			if _, _, err := pipeline.ParseRange(arg, "", now, loc); err != nil {
+				return "", "", fmt.Errorf("引数 %s を解釈できません", arg)
+			}
+			period = arg
+		}
+	}
+	if channel == "" {
+		return "", "", fmt.Errorf("チャンネルを指定してください")
+	}
+	return channel, period, nil
+}
+
+// enqueue はリクエストを実行待ちに追加する（実行待ちが一杯の場合はfalseを返す）
+func (h *SlackHandler) enqueue(req slackRequest) bool {
+	select {
+	case h.queue <- req:
+		return true
+	default:
+		return false
+	}
+}
+
+// work は実行待ちのリクエストを順にワードクラウドにして投稿する
+func (h *SlackHandler) work() {
+	defer h.wg.Done()
+	for req := range h.queue {
+		start := time.Now()
+		if err := h.run(req); err != nil {
+			h.logger.Error("Slackからのリクエストの処理に失敗しました", "channel", req.channel, "post", req.post, "user", req.user, "duration", time.Since(start), "error", err)
+			h.reply(req, fmt.Sprintf("<#%s> のワードクラウドを作成できませんでした: %v", req.channel, err))
+		}
+	}
+}
+
+// run はワードクラウドを一時ディレクトリに作成して投稿する
+// 実行したチャンネル以外を対象にする場合は、対象が公開チャンネルで、リクエストしたユーザーがそのメンバーの場合だけ作成する
+// 非公開チャンネルの内容が投稿先のチャンネルのメンバーに公開されないよう、非公開チャンネルはそのチャンネルへの投稿に限る
+func (h *SlackHandler) run(req slackRequest) error {
+	if req.channel != req.post {
+		info, err := h.client.GetChannelInfo(req.channel)
+		if err != nil {
+			return err
+		}
+		if info.IsPrivate {
+			return errPrivateChannel
+		}
+		member, err := h.client.IsMember(req.channel, req.user)
+		if err != nil {
+			return err
+		}
+		if !member {
+			return errNotMember
+		}
+	}
+
+	oldest, latest, err := pipeline.ParseRange(req.period, "", h.now(), h.location)
+	if err != nil {
+		return err
+	}
+
+	dir, err := os.MkdirTemp("", "wordcloud-slack-")
+	if err != nil {
+		return fmt.Errorf("一時ディレクトリの作成に失敗: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	result, err := h.pipeline.Run(pipeline.Request{
+		Channel: req.channel,
+		Oldest:  oldest,
+		Latest:  latest,
+		Outputs: []string{filepath.Join(dir, fmt.Sprintf("wordcloud_%s.png", req.channel))},
+		Post:    req.post,
+		Thread:  req.thread,
+	})
+	if err != nil {
+		return err
+	}
+
+	h.logger.Info("ワードクラウドを投稿しました", "channel", req.channel, "post", req.post, "user", req.user, "messages", len(result.Messages))
+	if req.responseURL != "" {
+		if err := slack.Respond(req.responseURL, slack.Response{
+			ResponseType:    slack.ResponseEphemeral,
+			Text:            fmt.Sprintf("<#%s> のワードクラウドを投稿しました", req.channel),
+			ReplaceOriginal: true,
+		}); err != nil {
+			h.logger.Warn("スラッシュコマンドへの応答に失敗しました", "channel", req.channel, "error", err)
+		}
+	}
+	return nil
+}
+
+// reply はリクエストの送信元にテキストで応答する
+// スラッシュコマンドにはresponse_urlで本人だけに、メンションにはスレッドに返信する
+func (h *SlackHandler) reply(req slackRequest, text string) {
+	var err error
+	if req.responseURL != "" {
+		err = slack.Respond(req.responseURL, slack.Response{ResponseType: slack.ResponseEphemeral, Text: text})
+	} else {
+		err = h.client.PostMessage(req.post, req.thread, text)
+	}
+	if err != nil {
+		h.logger.Warn("Slackへの応答に失敗しました", "channel", req.channel, "post", req.post, "error", err)
+	}
+}
+
+// writeSlackResponse はスラッシュコマンドを実行したユーザーだけに表示する応答を書き込む
+func writeSlackResponse(w http.ResponseWriter, text string) {
+	writeJSON(w, http.StatusOK, slack.Response{ResponseType: slack.ResponseEphemeral, Text: text})
+}
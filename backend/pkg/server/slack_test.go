@@ -0,0 +1,186 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Tattsum/wordcloud/backend/pkg/slack"
+)
+
+const testSigningSecret = "test-signing-secret"
+
+// testNow はテストで使う現在時刻
+var testNow = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// newTestSlackHandler はワーカーを開始せず、実行待ちのリクエストを直接確認できるSlackHandlerを作成する
+func newTestSlackHandler(client *slack.Client) *SlackHandler {
+	h := newSlackHandler(testSigningSecret, client, nil, time.UTC)
+	h.now = func() time.Time { return testNow }
+	return h
+}
+
+// signedRequest はSigning Secretで署名したリクエストを作成する
+func signedRequest(path, contentType, body string) *http.Request {
+	timestamp := strconv.FormatInt(testNow.Unix(), 10)
+	r := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	r.Header.Set("Content-Type", contentType)
+	r.Header.Set("X-Slack-Request-Timestamp", timestamp)
+	r.Header.Set("X-Slack-Signature", slack.Signature(testSigningSecret, timestamp, []byte(body)))
+	return r
+}
+
+func TestSlackHandlerCommand(t *testing.T) {
+	h := newTestSlackHandler(nil)
+	form := url.Values{
+		"channel_id":   {"C0123456789"},
+		"user_id":      {"U0123456789"},
+		"command":      {"/wordcloud"},
+		"text":         {"<#C9876543210|random> 30d"},
+		"response_url": {"https://hooks.slack.com/commands/T0001/1/abc"},
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, signedRequest("/slack/commands", "application/x-www-form-urlencoded", form.Encode()))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	var resp slack.Response
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("応答を解析できない: %v", err)
+	}
+	if resp.ResponseType != slack.ResponseEphemeral || !strings.Contains(resp.Text, "<#C9876543210>") {
+		t.Errorf("応答 = %+v", resp)
+	}
+
+	select {
+	case req := <-h.queue:
+		want := slackRequest{
+			channel:     "C9876543210",
+			period:      "30d",
+			user:        "U0123456789",
+			post:        "C0123456789",
+			responseURL: "https://hooks.slack.com/commands/T0001/1/abc",
+		}
+		if req != want {
+			t.Errorf("実行待ちのリクエスト = %+v, want %+v", req, want)
+		}
+	default:
+		t.Fatal("リクエストが実行待ちに追加されていない")
+	}
+}
+
+func TestSlackHandlerUnsigned(t *testing.T) {
+	h := newTestSlackHandler(nil)
+	r := signedRequest("/slack/commands", "application/x-www-form-urlencoded", "channel_id=C0123456789&text=30d")
+	r.Header.Set("X-Slack-Signature", "v0=invalid")
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+	if len(h.queue) != 0 {
+		t.Error("署名を検証できないリクエストが実行待ちに追加された")
+	}
+}
+
+func TestSlackHandlerURLVerification(t *testing.T) {
+	h := newTestSlackHandler(nil)
+	body := `{"token":"xyzzy","challenge":"3eZbrw1aBm2rZgRNFdxV2595E9CY3gmdALWMmHkvFXO7tYXAYM8P","type":"url_verification"}`
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, signedRequest("/slack/events", "application/json", body))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	var resp map[string]string
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("応答を解析できない: %v", err)
+	}
+	if resp["challenge"] != "3eZbrw1aBm2rZgRNFdxV2595E9CY3gmdALWMmHkvFXO7tYXAYM8P" {
+		t.Errorf("challenge = %q", resp["challenge"])
+	}
+}
+
+func TestSlackHandlerMention(t *testing.T) {
+	h := newTestSlackHandler(nil)
+	body := `{"type":"event_callback","event":{"type":"app_mention","text":"<@U0BOT> C9876543210 30d","user":"U0123456789","channel":"C0123456789","ts":"1767225600.000100"}}`
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, signedRequest("/slack/events", "application/json", body))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	select {
+	case req := <-h.queue:
+		want := slackRequest{
+			channel: "C9876543210",
+			period:  "30d",
+			user:    "U0123456789",
+			post:    "C0123456789",
+			thread:  "1767225600.000100",
+		}
+		if req != want {
+			t.Errorf("実行待ちのリクエスト = %+v, want %+v", req, want)
+		}
+	default:
+		t.Fatal("リクエストが実行待ちに追加されていない")
+	}
+}
+
+func TestSlackHandlerRunOtherChannel(t *testing.T) {
+	tests := []struct {
+		name    string
+		private bool
+		members []string
+		want    error
+	}{
+		{name: "公開チャンネルのメンバーでない", members: []string{"U0000000001"}, want: errNotMember},
+		{name: "非公開チャンネルはメンバーでもほかのチャンネルに投稿しない", private: true, members: []string{"U0123456789"}, want: errPrivateChannel},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/api/conversations.info":
+					writeJSON(w, http.StatusOK, map[string]any{
+						"ok":      true,
+						"channel": map[string]any{"id": "C9876543210", "is_channel": !tt.private, "is_private": tt.private},
+					})
+				case "/api/conversations.members":
+					if tt.private {
+						t.Error("非公開チャンネルのメンバーを確認した")
+					}
+					writeJSON(w, http.StatusOK, map[string]any{
+						"ok":                true,
+						"members":           tt.members,
+						"response_metadata": map[string]string{"next_cursor": ""},
+					})
+				default:
+					t.Errorf("想定外のAPI呼び出し: %s", r.URL.Path)
+					http.NotFound(w, r)
+				}
+			}))
+			defer api.Close()
+
+			client := slack.NewClient(slack.ClientConfig{Token: "xoxb-test", RateLimit: time.Millisecond, APIURL: api.URL + "/api/"})
+			h := newTestSlackHandler(client)
+
+			err := h.run(slackRequest{channel: "C9876543210", period: "30d", user: "U0123456789", post: "C0123456789"})
+			if !errors.Is(err, tt.want) {
+				t.Errorf("run() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
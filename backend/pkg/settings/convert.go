@@ -0,0 +1,164 @@
+package settings
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/Tattsum/wordcloud/backend/pkg/slack"
+	"github.com/Tattsum/wordcloud/backend/pkg/wordcloud"
+)
+
+// Location はタイムゾーンを読み込む
+func (s *Settings) Location() (*time.Location, error) {
+	location, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("タイムゾーンの読み込みに失敗: %w", err)
+	}
+	return location, nil
+}
+
+// WordcloudConfig は集計と描画の設定からwordcloud.Configを作成する
+// 同義語・参照コーパス・感情辞書・パレットのファイルもここで読み込む
+func (s *Settings) WordcloudConfig() (wordcloud.Config, error) {
+	location, err := s.Location()
+	if err != nil {
+		return wordcloud.Config{}, err
+	}
+
+	a, r := s.Analyze, s.Render
+	config := wordcloud.Config{
+		MinCount:    a.MinCount,
+		MaxWords:    a.MaxWords,
+		MinFontSize: r.MinFontSize,
+		MaxFontSize: r.MaxFontSize,
+		ColorScheme: r.Color,
+		Width:       r.Width,
+		Height:      r.Height,
+		FontPath:    r.Font,
+
+		Background:  r.Background,
+		MinContrast: r.MinContrast,
+
+		FontScale:       r.FontScale,
+		RelativeScaling: r.RelativeScaling,
+		FitCanvas:       r.Fit,
+
+		NGramSize:  a.NGram,
+		MaxPhrases: a.MaxPhrases,
+
+		Weighting:    a.Weighting,
+		DocumentUnit: a.DocumentUnit,
+		TimeLocation: location,
+
+		ExcludeUsers: a.ExcludeUsers,
+		Filter:       a.Filter,
+	}
+
+	if config.Palette, err = wordcloud.LoadPalette(r.Palette); err != nil {
+		return wordcloud.Config{}, fmt.Errorf("パレットの読み込みに失敗: %w", err)
+	}
+	if a.Reference != "" {
+		if config.Reference, err = wordcloud.LoadReferenceCorpus(a.Reference); err != nil {
+			return wordcloud.Config{}, fmt.Errorf("参照コーパスの読み込みに失敗: %w", err)
+		}
+	}
+	if a.Synonyms != "" {
+		if config.Synonyms, err = wordcloud.LoadSynonymFile(a.Synonyms); err != nil {
+			return wordcloud.Config{}, fmt.Errorf("同義語ファイルの読み込みに失敗: %w", err)
+		}
+	}
+	if a.Sentiment != "" {
+		if config.Sentiment, err = wordcloud.LoadSentimentLexicon(a.Sentiment); err != nil {
+			return wordcloud.Config{}, fmt.Errorf("感情辞書の読み込みに失敗: %w", err)
+		}
+	}
+	return config, nil
+}
+
+// AnalyzerOptions は正規化処理とストップワードのAnalyzerオプションを返す
+func (s *Settings) AnalyzerOptions() ([]wordcloud.Option, error) {
+	normalizers, err := wordcloud.ParseNormalizers(strings.Join(s.Analyze.Normalize, ","))
+	if err != nil {
+		return nil, fmt.Errorf("正規化処理の指定が不正です: %w", err)
+	}
+
+	stopWords, err := wordcloud.LoadStopWords(s.Analyze.StopWords...)
+	if err != nil {
+		return nil, fmt.Errorf("ストップワードの読み込みに失敗: %w", err)
+	}
+
+	return []wordcloud.Option{
+		wordcloud.WithNormalizers(normalizers),
+		wordcloud.WithStopWordRules(stopWords),
+	}, nil
+}
+
+// NewFileProcessor は設定からFileProcessorを作成する（ログは既定のロガーに出力する）
+// modifyを指定した場合はFileProcessorの作成前にwordcloud.Configを変更できる
+func (s *Settings) NewFileProcessor(modify ...func(*wordcloud.Config)) (*wordcloud.FileProcessor, error) {
+	config, err := s.WordcloudConfig()
+	if err != nil {
+		return nil, err
+	}
+	for _, fn := range modify {
+		fn(&config)
+	}
+
+	options, err := s.AnalyzerOptions()
+	if err != nil {
+		return nil, err
+	}
+	analyzer, err := wordcloud.NewAnalyzer(options...)
+	if err != nil {
+		return nil, fmt.Errorf("アナライザーの初期化に失敗: %w", err)
+	}
+	return wordcloud.NewFileProcessor(config, analyzer, wordcloud.WithLogger(slog.Default()))
+}
+
+// ClientConfig はSlackクライアントの設定を返す
+func (s *Settings) ClientConfig() slack.ClientConfig {
+	return slack.ClientConfig{
+		Token:          strings.TrimSpace(s.Slack.Token),
+		RateLimit:      time.Duration(s.Slack.RateLimit),
+		MaxConcurrency: s.Slack.MaxConcurrency,
+		APIURL:         strings.TrimSpace(s.Slack.APIURL),
+	}
+}
+
+// ExportOptions はCSVへのエクスポートのオプションを返す
+func (s *Settings) ExportOptions() ([]slack.ExportOption, error) {
+	location, err := s.Location()
+	if err != nil {
+		return nil, err
+	}
+
+	options := []slack.ExportOption{
+		slack.WithOutputDir(s.Slack.OutputDir),
+		slack.WithTimeLocation(location),
+	}
+	if !s.Slack.IncludeThread {
+		options = append(options, slack.WithoutThread())
+	}
+	return options, nil
+}
+
+// Logger はログの設定からwに書き込むロガーを作成する
+func (s *Settings) Logger(w io.Writer) (*slog.Logger, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(s.Log.Level)); err != nil {
+		return nil, fmt.Errorf("ログのレベルが不正です: %s", s.Log.Level)
+	}
+
+	options := &slog.HandlerOptions{Level: level}
+	switch strings.ToLower(s.Log.Format) {
+	case "", "text":
+		return slog.New(slog.NewTextHandler(w, options)), nil
+	case "json":
+		return slog.New(slog.NewJSONHandler(w, options)), nil
+	default:
+		return nil, fmt.Errorf("ログの出力形式が不正です: %s（text・jsonのいずれかを指定してください）", s.Log.Format)
+	}
+}
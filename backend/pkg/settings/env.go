@@ -0,0 +1,176 @@
+package settings
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EnvPrefix は設定を上書きする環境変数の接頭辞
+// 各項目は WORDCLOUD_<セクション>_<キー> （例: WORDCLOUD_RENDER_FONT）で上書きできる
+const EnvPrefix = "WORDCLOUD_"
+
+// envAliases は接頭辞なしで受け付ける環境変数と設定項目の対応
+var envAliases = map[string]string{
+	"SLACK_TOKEN":          "slack.token",
+	"SLACK_CHANNEL":        "slack.channel",
+	"SLACK_SIGNING_SECRET": "serve.signing_secret",
+}
+
+// applyEnv は環境変数の値で設定を上書きする
+func (s *Settings) applyEnv(lookup func(string) (string, bool)) error {
+	fields := make(map[string]reflect.Value)
+	collectFields(reflect.ValueOf(s).Elem(), "", fields)
+
+	// 別名より接頭辞付きの環境変数を優先する
+	for name, key := range envAliases {
+		if value, ok := lookup(name); ok {
+			if err := setField(fields[key], value); err != nil {
+				return fmt.Errorf("環境変数 %s の値が不正です: %w", name, err)
+			}
+		}
+	}
+	for key, field := range fields {
+		name := EnvName(key)
+		if value, ok := lookup(name); ok {
+			if err := setField(field, value); err != nil {
+				return fmt.Errorf("環境変数 %s の値が不正です: %w", name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// EnvName は設定項目（例: render.font）を上書きする環境変数名を返す
+func EnvName(key string) string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// collectFields は構造体の各項目を "セクション.キー" の形式で集める
+func collectFields(v reflect.Value, prefix string, fields map[string]reflect.Value) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		key := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if key == "" || key == "-" {
+			continue
+		}
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+
+		field := v.Field(i)
+		if _, ok := field.Addr().Interface().(flag.Value); !ok && field.Kind() == reflect.Struct {
+			collectFields(field, key, fields)
+			continue
+		}
+		fields[key] = field
+	}
+}
+
+// setField は文字列の値を項目の型に変換して設定する
+func setField(field reflect.Value, value string) error {
+	if v, ok := field.Addr().Interface().(flag.Value); ok {
+		return v.Set(value)
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(n))
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	default:
+		return fmt.Errorf("未対応の型です: %s", field.Type())
+	}
+	return nil
+}
+
+// List はカンマ区切りの文字列または配列で指定する文字列のリスト
+type List []string
+
+// String はカンマ区切りの文字列を返す
+func (l *List) String() string {
+	if l == nil {
+		return ""
+	}
+	return strings.Join(*l, ",")
+}
+
+// Set はカンマ区切りの文字列を解析する
+func (l *List) Set(value string) error {
+	*l = nil
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			*l = append(*l, item)
+		}
+	}
+	return nil
+}
+
+// UnmarshalJSON は文字列の配列またはカンマ区切りの文字列を読み込む
+func (l *List) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		return l.Set(s)
+	}
+	var items []string
+	if err := json.Unmarshal(data, &items); err != nil {
+		return fmt.Errorf("文字列またはその配列を指定してください: %s", data)
+	}
+	*l = items
+	return nil
+}
+
+// Duration は "1s" や "500ms" の形式の文字列または秒数で指定する時間
+type Duration time.Duration
+
+// String は時間の文字列を返す
+func (d *Duration) String() string {
+	if d == nil {
+		return "0s"
+	}
+	return time.Duration(*d).String()
+}
+
+// Set は時間の文字列を解析する
+func (d *Duration) Set(value string) error {
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// UnmarshalJSON は時間の文字列または秒数を読み込む
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var seconds float64
+	if err := json.Unmarshal(data, &seconds); err == nil {
+		*d = Duration(seconds * float64(time.Second))
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("時間の文字列または秒数を指定してください: %s", data)
+	}
+	return d.Set(s)
+}
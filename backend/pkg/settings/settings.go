@@ -0,0 +1,233 @@
+// Package settings はwordcloudコマンドの設定ファイル（YAML/TOML/JSON）と環境変数を読み込む
+package settings
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+
+	"github.com/Tattsum/wordcloud/backend/pkg/schedule"
+	"github.com/Tattsum/wordcloud/backend/pkg/wordcloud"
+)
+
+// Settings はコマンド全体の設定
+// 設定ファイルではキーをスネークケースで記述し、profilesの下にプロファイルごとの差分を書く
+type Settings struct {
+	Timezone string   `json:"timezone"` // 日付の判定とファイル名に使うタイムゾーン
+	Slack    Slack    `json:"slack"`    // Slackからのメッセージ取得
+	Analyze  Analyze  `json:"analyze"`  // 単語の集計
+	Render   Render   `json:"render"`   // 画像の描画
+	Serve    Serve    `json:"serve"`    // HTTPサーバー
+	Schedule Schedule `json:"schedule"` // 定期実行
+	Log      Log      `json:"log"`      // ログの出力
+}
+
+// Slack はslack.ClientConfigとslack.ExportOptionsに対応する設定
+type Slack struct {
+	Token          string   `json:"token"`           // Bot User OAuth Token（環境変数SLACK_TOKENでも指定できる）
+	Channel        string   `json:"channel"`         // チャンネルID
+	RateLimit      Duration `json:"rate_limit"`      // API呼び出しの最小間隔
+	MaxConcurrency int      `json:"max_concurrency"` // 同時に実行するAPI呼び出しの最大数
+	OutputDir      string   `json:"output_dir"`      // CSVファイルの出力先ディレクトリ
+	IncludeThread  bool     `json:"include_thread"`  // CSVにスレッドの列を含める
+	CacheDir       string   `json:"cache_dir"`       // pipelineで取得したメッセージのキャッシュ先（空の場合はキャッシュしない）
+	CacheTTL       Duration `json:"cache_ttl"`       // 期間が現在を含む場合にキャッシュを再利用する時間
+	PostChannel    string   `json:"post_channel"`    // pipelineで作成した画像を投稿するチャンネルID（空の場合は投稿しない）
+	APIURL         string   `json:"api_url"`         // Slack APIのベースURL（空の場合は既定）
+}
+
+// Analyze はwordcloud.Configのうち単語の集計に関する設定
+type Analyze struct {
+	MinCount     int    `json:"min_count"`     // 最小出現回数
+	MaxWords     int    `json:"max_words"`     // 最大単語数
+	StopWords    List   `json:"stopwords"`     // ストップワードの読み込み元（preset:ja・ファイルパスなど）
+	Normalize    List   `json:"normalize"`     // 形態素解析の前に適用する正規化処理
+	Synonyms     string `json:"synonyms"`      // 同義語ファイルのパス
+	Weighting    string `json:"weighting"`     // 単語の重み付け方式
+	DocumentUnit string `json:"document_unit"` // TF-IDFで文書とみなす単位
+	Reference    string `json:"reference"`     // 対数尤度比の参照コーパスのパス
+	NGram        int    `json:"ngram"`         // フレーズとして集計する最大の単語数
+	MaxPhrases   int    `json:"max_phrases"`   // 出力に含めるフレーズの最大数
+	ExcludeUsers List   `json:"exclude_users"` // 集計から除外するユーザー
+	Filter       string `json:"filter"`        // メッセージのフィルター式
+	Sentiment    string `json:"sentiment"`     // 感情辞書のパス
+}
+
+// Render はwordcloud.Configのうち画像の描画に関する設定
+type Render struct {
+	Width           int     `json:"width"`            // 画像の幅
+	Height          int     `json:"height"`           // 画像の高さ
+	Font            string  `json:"font"`             // TrueTypeフォントのパス
+	MinFontSize     int     `json:"min_font_size"`    // 最小フォントサイズ
+	MaxFontSize     int     `json:"max_font_size"`    // 最大フォントサイズ
+	FontScale       string  `json:"font_scale"`       // フォントサイズの割り当て方式
+	RelativeScaling float64 `json:"relative_scaling"` // relativeで値の比を反映する度合い
+	Fit             bool    `json:"fit"`              // 単語が画像を埋めるようにフォントサイズを調整する
+	Color           string  `json:"color"`            // 色スキーム
+	Palette         string  `json:"palette"`          // 色スキームのパレット
+	Background      string  `json:"background"`       // 背景色
+	MinContrast     float64 `json:"min_contrast"`     // 背景色との最小コントラスト比
+}
+
+// Serve はHTTPサーバーの設定
+type Serve struct {
+	Addr          string `json:"addr"`           // 待ち受けるアドレス
+	SigningSecret string `json:"signing_secret"` // スラッシュコマンドとEvents APIの署名を検証するSigning Secret（環境変数SLACK_SIGNING_SECRETでも指定できる）
+	JobToken      string `json:"job_token"`      // ジョブのAPIのリクエストに求めるBearerトークン
+	JobChannels   List   `json:"job_channels"`   // ジョブのAPIで受け付けるチャンネル（空の場合は制限しない）
+}
+
+// Schedule はserve -scheduleで定期的に実行するジョブの設定
+type Schedule struct {
+	Jobs       []schedule.Job `json:"jobs"`        // 実行するジョブ
+	History    string         `json:"history"`     // 実行履歴を追記するJSON Linesファイルのパス
+	LockDir    string         `json:"lock_dir"`    // 同じジョブの重複実行を防ぐロックファイルのディレクトリ
+	Retries    int            `json:"retries"`     // 失敗したチャンネルを再試行する回数
+	RetryDelay Duration       `json:"retry_delay"` // 最初の再試行までの間隔（以降は2倍ずつ延ばす）
+}
+
+// Log はログの出力形式とレベルの設定
+type Log struct {
+	Format string `json:"format"` // 出力形式（text・json）
+	Level  string `json:"level"`  // 出力する最低のレベル（debug・info・warn・error）
+}
+
+// Default はコマンドの既定の設定を返す
+func Default() *Settings {
+	return &Settings{
+		Timezone: "Asia/Tokyo",
+		Slack: Slack{
+			RateLimit:      Duration(time.Second),
+			MaxConcurrency: 5,
+			OutputDir:      "data",
+			IncludeThread:  true,
+			CacheTTL:       Duration(time.Hour),
+		},
+		Analyze: Analyze{
+			MinCount:     2,
+			MaxWords:     100,
+			Normalize:    List(wordcloud.DefaultNormalizerNames),
+			Weighting:    wordcloud.WeightingCount,
+			DocumentUnit: wordcloud.DocumentMessage,
+			MaxPhrases:   20,
+		},
+		Render: Render{
+			Width:           800,
+			Height:          600,
+			MinFontSize:     12,
+			MaxFontSize:     48,
+			RelativeScaling: 0.5,
+			Color:           wordcloud.ColorBlue,
+			Background:      "#FFFFFF",
+			MinContrast:     3.0,
+		},
+		Serve: Serve{
+			Addr: ":8080",
+		},
+		Schedule: Schedule{
+			History:    "data/schedule/history.jsonl",
+			LockDir:    "data/schedule/locks",
+			Retries:    2,
+			RetryDelay: Duration(time.Minute),
+		},
+		Log: Log{
+			Format: "text",
+			Level:  "info",
+		},
+	}
+}
+
+// profilesKey は設定ファイルでプロファイルを定義するキー
+const profilesKey = "profiles"
+
+// Load は既定の設定に設定ファイルとプロファイル、環境変数の順に値を重ねた設定を返す
+// pathが空の場合は設定ファイルを読まず、profileが空の場合はプロファイルを適用しない
+func Load(path, profile string) (*Settings, error) {
+	s := Default()
+
+	if path != "" {
+		values, err := readFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		profiles, _ := values[profilesKey].(map[string]any)
+		delete(values, profilesKey)
+		if profile != "" {
+			overrides, ok := profiles[profile].(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("プロファイルが見つかりません: %s", profile)
+			}
+			merge(values, overrides)
+		}
+
+		if err := decode(values, s); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	} else if profile != "" {
+		return nil, fmt.Errorf("プロファイル %s の指定には設定ファイルが必要です", profile)
+	}
+
+	if err := s.applyEnv(os.LookupEnv); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// readFile は拡張子から形式を判定して設定ファイルを読み込む
+func readFile(path string) (map[string]any, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("設定ファイルの読み込みに失敗: %w", err)
+	}
+
+	values := make(map[string]any)
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &values)
+	case ".toml":
+		err = toml.Unmarshal(data, &values)
+	case ".json":
+		err = json.Unmarshal(data, &values)
+	default:
+		return nil, fmt.Errorf("設定ファイルの形式を拡張子から判定できません: %s", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("設定ファイルのパースに失敗: %w", err)
+	}
+	return values, nil
+}
+
+// merge はoverridesの値をvaluesに重ねる（入れ子のテーブルはキーごとに重ねる）
+func merge(values, overrides map[string]any) {
+	for key, override := range overrides {
+		if sub, ok := override.(map[string]any); ok {
+			if base, ok := values[key].(map[string]any); ok {
+				merge(base, sub)
+				continue
+			}
+		}
+		values[key] = override
+	}
+}
+
+// decode は設定ファイルの値をSettingsに設定する（未知のキーはエラーとする）
+func decode(values map[string]any, s *Settings) error {
+	data, err := json.Marshal(values)
+	if err != nil {
+		return fmt.Errorf("設定値の変換に失敗: %w", err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(s); err != nil {
+		return fmt.Errorf("設定値が不正です: %w", err)
+	}
+	return nil
+}
@@ -2,7 +2,8 @@ package slack
 
 import (
 	"fmt"
-	"log"
+	"log/slog"
+	"strings"
 	"sync"
 	"time"
 
@@ -29,6 +30,7 @@ type Client struct {
 	lastCall       time.Time
 	sem            *semaphore.Weighted
 	maxConcurrency int
+	logger         *slog.Logger
 }
 
 // ClientConfig はクライアントの設定オプション
@@ -36,10 +38,11 @@ type ClientConfig struct {
 	Token          string
 	RateLimit      time.Duration
 	MaxConcurrency int
+	APIURL         string // Slack APIのベースURL（ローカルの偽サーバーで試す場合など。空の場合は既定）
 }
 
 // NewClient は新しいSlackクライアントを作成
-func NewClient(config ClientConfig) *Client {
+func NewClient(config ClientConfig, options ...ClientOption) *Client {
 	if config.RateLimit == 0 {
 		config.RateLimit = time.Second
 	}
@@ -47,12 +50,26 @@ func NewClient(config ClientConfig) *Client {
 		config.MaxConcurrency = 5
 	}
 
-	return &Client{
-		api:            slack.New(config.Token),
+	var apiOptions []slack.Option
+	if config.APIURL != "" {
+		apiURL := config.APIURL
+		if !strings.HasSuffix(apiURL, "/") {
+			apiURL += "/"
+		}
+		apiOptions = append(apiOptions, slack.OptionAPIURL(apiURL))
+	}
+
+	c := &Client{
+		api:            slack.New(config.Token, apiOptions...),
 		rateLimit:      config.RateLimit,
 		maxConcurrency: config.MaxConcurrency,
 		sem:            semaphore.NewWeighted(int64(config.MaxConcurrency)),
+		logger:         discardLogger,
+	}
+	for _, opt := range options {
+		opt(c)
 	}
+	return c
 }
 
 // waitForRateLimit はレートリミットを制御
@@ -68,7 +85,8 @@ func (c *Client) waitForRateLimit() {
 }
 
 func (c *Client) GetChannelMessages(channelID string, options ...MessageOption) ([]SlackMessage, error) {
-	log.Printf("チャンネル %s のメッセージ取得を開始します", channelID)
+	c.logger.Debug("メッセージの取得を開始します", "channel", channelID)
+	start := time.Now()
 
 	opts := defaultMessageOptions()
 	for _, opt := range options {
@@ -77,14 +95,16 @@ func (c *Client) GetChannelMessages(channelID string, options ...MessageOption)
 
 	var allMessages []SlackMessage
 	cursor := ""
+	fetched := 0
 
-	for {
+	for page := 1; ; page++ {
 		// メッセージページを取得
-		log.Printf("メッセージページを取得中...")
 		params := &slack.GetConversationHistoryParameters{
 			ChannelID: channelID,
 			Cursor:    cursor,
 			Limit:     100,
+			Oldest:    timestamp(opts.oldest),
+			Latest:    timestamp(opts.latest),
 		}
 
 		c.waitForRateLimit()
@@ -93,8 +113,6 @@ func (c *Client) GetChannelMessages(channelID string, options ...MessageOption)
 			return nil, fmt.Errorf("メッセージの取得に失敗: %w", err)
 		}
 
-		log.Printf("%d 件のメッセージを取得しました。処理を開始します...", len(history.Messages))
-
 		// メッセージを処理
 		for _, msg := range history.Messages {
 			message := SlackMessage{
@@ -107,16 +125,21 @@ func (c *Client) GetChannelMessages(channelID string, options ...MessageOption)
 
 			// スレッドの返信を取得
 			if msg.ThreadTimestamp != "" && msg.ThreadTimestamp == msg.Timestamp {
-				log.Printf("スレッド %s の返信を取得中...", msg.ThreadTimestamp)
-				replies, err := c.getThreadReplies(channelID, msg.ThreadTimestamp)
+				replies, err := c.getThreadReplies(channelID, msg.ThreadTimestamp, opts)
 				if err != nil {
 					return nil, fmt.Errorf("スレッド返信の取得に失敗: %w", err)
 				}
 				message.Replies = replies
+				fetched += len(replies)
 			}
 
 			allMessages = append(allMessages, message)
 		}
+		fetched += len(history.Messages)
+		c.logger.Debug("メッセージのページを取得しました", "channel", channelID, "page", page, "count", len(history.Messages), "total", fetched)
+		if opts.progress != nil {
+			opts.progress.Report(StageFetchMessages, fetched, 0)
+		}
 
 		// 次のページがなければ終了
 		if !history.HasMore {
@@ -125,10 +148,11 @@ func (c *Client) GetChannelMessages(channelID string, options ...MessageOption)
 		cursor = history.ResponseMetaData.NextCursor
 	}
 
+	c.logger.Info("メッセージの取得が完了しました", "channel", channelID, "count", fetched, "duration", time.Since(start))
 	return allMessages, nil
 }
 
-func (c *Client) getThreadReplies(channelID, threadTS string) ([]SlackMessage, error) {
+func (c *Client) getThreadReplies(channelID, threadTS string, opts *messageOptions) ([]SlackMessage, error) {
 	var replies []SlackMessage
 	cursor := ""
 
@@ -138,6 +162,8 @@ func (c *Client) getThreadReplies(channelID, threadTS string) ([]SlackMessage, e
 			ChannelID: channelID,
 			Timestamp: threadTS,
 			Cursor:    cursor,
+			Oldest:    timestamp(opts.oldest),
+			Latest:    timestamp(opts.latest),
 		}
 
 		messages, hasMore, nextCursor, err := c.api.GetConversationReplies(params)
@@ -145,12 +171,11 @@ func (c *Client) getThreadReplies(channelID, threadTS string) ([]SlackMessage, e
 			return nil, fmt.Errorf("スレッド返信の取得に失敗: %w", err)
 		}
 
-		// 最初のメッセージはスキップ（親メッセージのため）
-		if len(messages) > 0 {
-			messages = messages[1:]
-		}
-
 		for _, msg := range messages {
+			// 親メッセージはスキップ（各ページの先頭に含まれるため）
+			if msg.Timestamp == threadTS {
+				continue
+			}
 			reply := SlackMessage{
 				ID:        msg.Timestamp,
 				Text:      msg.Text,
@@ -208,6 +233,49 @@ func (c *Client) JoinChannel(channelID string) error {
 	return nil
 }
 
+// IsMember はユーザーがチャンネルのメンバーかを判定（Botがメンバーを参照できないチャンネルはエラーになる）
+func (c *Client) IsMember(channelID, userID string) (bool, error) {
+	if userID == "" {
+		return false, nil
+	}
+
+	cursor := ""
+	for {
+		c.waitForRateLimit()
+		members, nextCursor, err := c.api.GetUsersInConversation(&slack.GetUsersInConversationParameters{
+			ChannelID: channelID,
+			Cursor:    cursor,
+			Limit:     1000,
+		})
+		if err != nil {
+			return false, fmt.Errorf("チャンネルのメンバーの取得に失敗: %w", err)
+		}
+		for _, member := range members {
+			if member == userID {
+				return true, nil
+			}
+		}
+		if nextCursor == "" {
+			return false, nil
+		}
+		cursor = nextCursor
+	}
+}
+
+// PostMessage はチャンネルにテキストのメッセージを投稿する（threadTSを指定した場合はスレッドへの返信にする）
+func (c *Client) PostMessage(channelID, threadTS, text string) error {
+	c.waitForRateLimit()
+
+	options := []slack.MsgOption{slack.MsgOptionText(text, false)}
+	if threadTS != "" {
+		options = append(options, slack.MsgOptionTS(threadTS))
+	}
+	if _, _, err := c.api.PostMessage(channelID, options...); err != nil {
+		return fmt.Errorf("メッセージの投稿に失敗: %w", err)
+	}
+	return nil
+}
+
 // Validate はトークンとBotの権限を検証
 func (c *Client) Validate() error {
 	c.waitForRateLimit()
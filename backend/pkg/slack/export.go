@@ -3,7 +3,6 @@ package slack
 import (
 	"encoding/csv"
 	"fmt"
-	"log"
 	"os"
 	"path/filepath"
 	"time"
@@ -14,6 +13,7 @@ type ExportOptions struct {
 	OutputDir     string
 	IncludeThread bool
 	TimeLocation  *time.Location
+	Progress      Progress // メッセージの取得の進捗の通知先
 }
 
 // defaultExportOptions はデフォルトのエクスポートオプションを返す
@@ -43,9 +43,18 @@ func WithoutThread() ExportOption {
 	}
 }
 
+// WithTimeLocation はファイル名の日時に使うタイムゾーンを指定するオプション
+func WithTimeLocation(loc *time.Location) ExportOption {
+	return func(opts *ExportOptions) {
+		if loc != nil {
+			opts.TimeLocation = loc
+		}
+	}
+}
+
 // ExportChannelMessages はチャンネルのメッセージをCSVに出力
 func (c *Client) ExportChannelMessages(channelID string, options ...ExportOption) (string, error) {
-	log.Println("メッセージのエクスポートを開始します")
+	c.logger.Debug("メッセージのエクスポートを開始します", "channel", channelID)
 
 	// オプションの設定
 	opts := defaultExportOptions()
@@ -54,12 +63,11 @@ func (c *Client) ExportChannelMessages(channelID string, options ...ExportOption
 	}
 
 	// チャンネル情報の取得
-	log.Println("チャンネル情報を取得中...")
 	channel, err := c.GetChannelInfo(channelID)
 	if err != nil {
 		return "", fmt.Errorf("チャンネル情報の取得に失敗: %w", err)
 	}
-	log.Printf("チャンネル情報を取得しました: %s", channel.Name)
+	c.logger.Debug("チャンネル情報を取得しました", "channel", channelID, "name", channel.Name)
 
 	// 出力ディレクトリの作成
 	if err := os.MkdirAll(opts.OutputDir, 0755); err != nil {
@@ -73,7 +81,7 @@ func (c *Client) ExportChannelMessages(channelID string, options ...ExportOption
 	)
 	filepath := filepath.Join(opts.OutputDir, filename)
 
-	log.Printf("CSVファイルを作成します: %s", filepath)
+	c.logger.Debug("CSVファイルを作成します", "path", filepath)
 	file, err := os.Create(filepath)
 	if err != nil {
 		return "", fmt.Errorf("CSVファイルの作成に失敗: %w", err)
@@ -93,18 +101,12 @@ func (c *Client) ExportChannelMessages(channelID string, options ...ExportOption
 	}
 
 	// メッセージの取得と書き込み
-	log.Println("メッセージを取得中...")
-	messages, err := c.GetChannelMessages(channelID, WithUserInfo())
+	messages, err := c.GetChannelMessages(channelID, WithUserInfo(), WithProgress(opts.Progress))
 	if err != nil {
 		return "", fmt.Errorf("メッセージの取得に失敗: %w", err)
 	}
 
-	log.Printf("CSVファイルに %d 件のメッセージを書き込み中...", len(messages))
-	for i, msg := range messages {
-		if i > 0 && i%1000 == 0 {
-			log.Printf("進捗: %d/%d 件処理完了", i, len(messages))
-		}
-
+	for _, msg := range messages {
 		record := []string{
 			msg.Timestamp,
 			msg.UserID,
@@ -120,6 +122,6 @@ func (c *Client) ExportChannelMessages(channelID string, options ...ExportOption
 		}
 	}
 
-	log.Printf("メッセージのエクスポートが完了しました: %s", filepath)
+	c.logger.Info("メッセージのエクスポートが完了しました", "channel", channelID, "path", filepath, "count", len(messages))
 	return filepath, nil
 }
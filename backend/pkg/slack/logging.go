@@ -0,0 +1,21 @@
+package slack
+
+import (
+	"io"
+	"log/slog"
+)
+
+// ClientOption はClientの設定オプション関数の型
+type ClientOption func(*Client)
+
+// WithLogger はログの出力先を設定するオプション（指定しない場合はログを出力しない）
+func WithLogger(logger *slog.Logger) ClientOption {
+	return func(c *Client) {
+		if logger != nil {
+			c.logger = logger
+		}
+	}
+}
+
+// discardLogger はロガーを指定しない場合に使う、何も出力しないロガー
+var discardLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
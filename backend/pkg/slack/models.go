@@ -1,6 +1,9 @@
 package slack
 
-import "time"
+import (
+	"fmt"
+	"time"
+)
 
 // Message はSlackメッセージの構造体
 type Message struct {
@@ -28,6 +31,9 @@ type Channel struct {
 type messageOptions struct {
 	limit           int
 	includeUserInfo bool
+	oldest          time.Time // この日時以降のメッセージに限定（ゼロ値は制限なし）
+	latest          time.Time // この日時より前のメッセージに限定（ゼロ値は制限なし）
+	progress        Progress  // 取得の進捗の通知先（nilの場合は通知しない）
 }
 
 // MessageOption はメッセージ取得のオプション関数
@@ -48,6 +54,23 @@ func WithLimit(limit int) MessageOption {
 	}
 }
 
+// WithTimeRange は取得するメッセージの投稿日時を[oldest, latest)に限定するオプション
+// ゼロ値を指定した側は制限しない。スレッドの返信も同じ範囲に限定する
+func WithTimeRange(oldest, latest time.Time) MessageOption {
+	return func(opts *messageOptions) {
+		opts.oldest = oldest
+		opts.latest = latest
+	}
+}
+
+// timestamp はSlack APIのoldest・latestに指定するタイムスタンプ文字列を返す
+func timestamp(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return fmt.Sprintf("%d.%06d", t.Unix(), t.Nanosecond()/int(time.Microsecond))
+}
+
 // WithUserInfo はユーザー情報を含めるオプション
 func WithUserInfo() MessageOption {
 	return func(opts *messageOptions) {
@@ -0,0 +1,25 @@
+package slack
+
+// StageFetchMessages はメッセージの取得の段階（スレッドの返信を含めた取得済みの件数を通知し、総数は0とする）
+const StageFetchMessages = "fetch_messages"
+
+// Progress はメッセージの取得の進捗を受け取るインターフェース
+// wordcloud.Progressと同じメソッドを持つため、同じ実装をどちらにも渡せる
+type Progress interface {
+	// Report は段階stageの処理がtotal件中done件まで進んだことを通知する（totalが0の場合は総数が不明）
+	Report(stage string, done, total int)
+}
+
+// WithProgress はメッセージの取得の進捗をページごとに通知するオプション
+func WithProgress(progress Progress) MessageOption {
+	return func(opts *messageOptions) {
+		opts.progress = progress
+	}
+}
+
+// WithExportProgress はエクスポートでのメッセージの取得の進捗を通知するオプション
+func WithExportProgress(progress Progress) ExportOption {
+	return func(opts *ExportOptions) {
+		opts.Progress = progress
+	}
+}
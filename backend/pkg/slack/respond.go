@@ -0,0 +1,46 @@
+package slack
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// 応答の表示範囲
+const (
+	ResponseEphemeral = "ephemeral"  // コマンドを実行したユーザーだけに表示
+	ResponseInChannel = "in_channel" // チャンネルの全員に表示
+)
+
+// Response はスラッシュコマンドのresponse_urlに送るメッセージ
+type Response struct {
+	ResponseType    string `json:"response_type,omitempty"`
+	Text            string `json:"text"`
+	ReplaceOriginal bool   `json:"replace_original,omitempty"`
+}
+
+// respondTimeout はresponse_urlへの送信のタイムアウト
+const respondTimeout = 10 * time.Second
+
+// Respond はスラッシュコマンドのresponse_urlにメッセージを送る
+// response_urlはコマンドの実行から30分間、5回まで使える
+func Respond(responseURL string, response Response) error {
+	data, err := json.Marshal(response)
+	if err != nil {
+		return fmt.Errorf("応答の作成に失敗: %w", err)
+	}
+
+	client := &http.Client{Timeout: respondTimeout}
+	resp, err := client.Post(responseURL, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("応答の送信に失敗: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("応答の送信に失敗: %s", resp.Status)
+	}
+	return nil
+}
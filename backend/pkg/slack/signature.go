@@ -0,0 +1,61 @@
+package slack
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ErrInvalidSignature はSlackからのリクエストの署名が不正なエラー
+var ErrInvalidSignature = errors.New("Slackリクエストの署名が不正です")
+
+// signatureMaxAge はリクエストのタイムスタンプとして受け付ける現在との差（リプレイ攻撃の対策）
+const signatureMaxAge = 5 * time.Minute
+
+// maxRequestBody はSlackからのリクエストとして読み込む本文の最大サイズ
+const maxRequestBody = 1 << 20
+
+// VerifyRequest はSigning Secretでリクエストの署名（X-Slack-Signature）を検証し、本文を返す
+// 本文は読み込んだ内容で置き換えるため、検証後もr.ParseFormなどで読み込める
+func VerifyRequest(r *http.Request, secret string, now time.Time) ([]byte, error) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxRequestBody))
+	if err != nil {
+		return nil, fmt.Errorf("リクエストの読み込みに失敗: %w", err)
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	timestamp := r.Header.Get("X-Slack-Request-Timestamp")
+	sec, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: タイムスタンプがありません", ErrInvalidSignature)
+	}
+	if age := now.Sub(time.Unix(sec, 0)); age > signatureMaxAge || age < -signatureMaxAge {
+		return nil, fmt.Errorf("%w: タイムスタンプが古すぎます", ErrInvalidSignature)
+	}
+
+	expected := Signature(secret, timestamp, body)
+	if !hmac.Equal([]byte(expected), []byte(r.Header.Get("X-Slack-Signature"))) {
+		return nil, ErrInvalidSignature
+	}
+	return body, nil
+}
+
+// Signature はSlackのリクエスト署名（v0=HMAC-SHA256）を計算する
+func Signature(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "v0:%s:", timestamp)
+	mac.Write(body)
+	return "v0=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// IsSignatureError は署名の検証エラーかを判定
+func IsSignatureError(err error) bool {
+	return errors.Is(err, ErrInvalidSignature)
+}
@@ -0,0 +1,79 @@
+package slack
+
+import (
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// 署名の検証に使うスラッシュコマンドのリクエスト
+// 署名は openssl dgst -sha256 -hmac で計算した値
+const (
+	fixtureSecret    = "test-signing-secret"
+	fixtureTimestamp = "1767225600"
+	fixtureBody      = "token=xyzzy&team_id=T0001&channel_id=C0123456789&user_id=U0123456789&command=%2Fwordcloud&text=30d&response_url=https%3A%2F%2Fhooks.slack.com%2Fcommands%2FT0001%2F1%2Fabc"
+	fixtureSignature = "v0=1c492acc7106fcbfc6502c4386832355a78a8332758dd2f7231c02962b91a481"
+)
+
+func TestSignature(t *testing.T) {
+	if got := Signature(fixtureSecret, fixtureTimestamp, []byte(fixtureBody)); got != fixtureSignature {
+		t.Errorf("Signature() = %s, want %s", got, fixtureSignature)
+	}
+}
+
+func TestVerifyRequest(t *testing.T) {
+	fixtureTime := time.Unix(1767225600, 0)
+
+	tests := []struct {
+		name      string
+		secret    string
+		body      string
+		timestamp string // 空の場合はヘッダーを付けない
+		signature string // 空の場合はヘッダーを付けない
+		now       time.Time
+		wantErr   bool
+	}{
+		{name: "正しい署名", secret: fixtureSecret, body: fixtureBody, timestamp: fixtureTimestamp, signature: fixtureSignature, now: fixtureTime},
+		{name: "許容範囲内の時刻のずれ", secret: fixtureSecret, body: fixtureBody, timestamp: fixtureTimestamp, signature: fixtureSignature, now: fixtureTime.Add(4 * time.Minute)},
+		{name: "改ざんされた本文", secret: fixtureSecret, body: strings.Replace(fixtureBody, "text=30d", "text=C9999999999", 1), timestamp: fixtureTimestamp, signature: fixtureSignature, now: fixtureTime, wantErr: true},
+		{name: "異なるSigning Secret", secret: "wrong-secret", body: fixtureBody, timestamp: fixtureTimestamp, signature: fixtureSignature, now: fixtureTime, wantErr: true},
+		{name: "古いタイムスタンプ", secret: fixtureSecret, body: fixtureBody, timestamp: fixtureTimestamp, signature: fixtureSignature, now: fixtureTime.Add(6 * time.Minute), wantErr: true},
+		{name: "未来のタイムスタンプ", secret: fixtureSecret, body: fixtureBody, timestamp: fixtureTimestamp, signature: fixtureSignature, now: fixtureTime.Add(-6 * time.Minute), wantErr: true},
+		{name: "タイムスタンプのヘッダーがない", secret: fixtureSecret, body: fixtureBody, signature: fixtureSignature, now: fixtureTime, wantErr: true},
+		{name: "署名のヘッダーがない", secret: fixtureSecret, body: fixtureBody, timestamp: fixtureTimestamp, now: fixtureTime, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("POST", "/slack/commands", strings.NewReader(tt.body))
+			if tt.timestamp != "" {
+				r.Header.Set("X-Slack-Request-Timestamp", tt.timestamp)
+			}
+			if tt.signature != "" {
+				r.Header.Set("X-Slack-Signature", tt.signature)
+			}
+
+			body, err := VerifyRequest(r, tt.secret, tt.now)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidSignature) {
+					t.Fatalf("VerifyRequest() error = %v, want ErrInvalidSignature", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("VerifyRequest() error = %v", err)
+			}
+			if string(body) != tt.body {
+				t.Errorf("VerifyRequest() body = %q, want %q", body, tt.body)
+			}
+
+			// 検証後も本文を読み込める
+			rest, err := io.ReadAll(r.Body)
+			if err != nil || string(rest) != tt.body {
+				t.Errorf("検証後の本文 = %q, %v", rest, err)
+			}
+		})
+	}
+}
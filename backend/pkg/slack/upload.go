@@ -0,0 +1,81 @@
+package slack
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/slack-go/slack"
+)
+
+// UploadedFile はアップロードしたファイルの情報
+type UploadedFile struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+}
+
+// uploadOptions はファイルのアップロードのオプション
+type uploadOptions struct {
+	title    string // ファイルのタイトル（空の場合はファイル名）
+	comment  string // ファイルと一緒に投稿するメッセージ
+	threadTS string // 返信として投稿するスレッド
+}
+
+// UploadOption はファイルのアップロードのオプション関数
+type UploadOption func(*uploadOptions)
+
+// WithTitle はファイルのタイトルを指定するオプション
+func WithTitle(title string) UploadOption {
+	return func(opts *uploadOptions) {
+		opts.title = title
+	}
+}
+
+// WithComment はファイルと一緒に投稿するメッセージを指定するオプション
+func WithComment(comment string) UploadOption {
+	return func(opts *uploadOptions) {
+		opts.comment = comment
+	}
+}
+
+// WithThread はスレッドへの返信として投稿するオプション
+func WithThread(threadTS string) UploadOption {
+	return func(opts *uploadOptions) {
+		opts.threadTS = threadTS
+	}
+}
+
+// UploadFile はファイルをチャンネルに投稿する
+// files.getUploadURLExternalで取得したURLにファイルを送信し、files.completeUploadExternalで共有する
+func (c *Client) UploadFile(channelID, path string, options ...UploadOption) (*UploadedFile, error) {
+	opts := &uploadOptions{}
+	for _, opt := range options {
+		opt(opts)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("アップロードするファイルの確認に失敗: %w", err)
+	}
+	if info.Size() == 0 {
+		return nil, fmt.Errorf("アップロードするファイルが空です: %s", path)
+	}
+
+	// アップロードは3回のAPI呼び出しになるが、まとめて1回分の間隔を空ける
+	c.waitForRateLimit()
+	file, err := c.api.UploadFileV2(slack.UploadFileV2Parameters{
+		File:            path,
+		FileSize:        int(info.Size()),
+		Filename:        filepath.Base(path),
+		Title:           opts.title,
+		InitialComment:  opts.comment,
+		Channel:         channelID,
+		ThreadTimestamp: opts.threadTS,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("ファイルのアップロードに失敗: %w", err)
+	}
+	c.logger.Info("ファイルをアップロードしました", "channel", channelID, "file", file.ID, "path", path, "size", info.Size())
+
+	return &UploadedFile{ID: file.ID, Title: file.Title}, nil
+}
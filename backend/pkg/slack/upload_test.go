@@ -0,0 +1,148 @@
+package slack
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeUpload はファイルのアップロードに使う3つのAPIを模したSlackのサーバー
+type fakeUpload struct {
+	server *httptest.Server
+
+	mu       sync.Mutex
+	calls    []string   // 呼び出された順のパス
+	file     []byte     // アップロードURLに送信されたファイル
+	complete url.Values // files.completeUploadExternalのパラメーター
+}
+
+func newFakeUpload(t *testing.T) *fakeUpload {
+	t.Helper()
+	f := &fakeUpload{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/files.getUploadURLExternal", func(w http.ResponseWriter, r *http.Request) {
+		f.record(r.URL.Path)
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("files.getUploadURLExternalのパラメーターを解析できない: %v", err)
+		}
+		if r.PostForm.Get("filename") == "" || r.PostForm.Get("length") == "" {
+			t.Errorf("files.getUploadURLExternalのパラメーターが不足: %v", r.PostForm)
+		}
+		writeFakeJSON(w, map[string]any{"ok": true, "upload_url": f.server.URL + "/upload/F0001", "file_id": "F0001"})
+	})
+	mux.HandleFunc("/upload/F0001", func(w http.ResponseWriter, r *http.Request) {
+		f.record(r.URL.Path)
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			t.Errorf("アップロードされたファイルを読み込めない: %v", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+		data, _ := io.ReadAll(file)
+		f.mu.Lock()
+		f.file = data
+		f.mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/api/files.completeUploadExternal", func(w http.ResponseWriter, r *http.Request) {
+		f.record(r.URL.Path)
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("files.completeUploadExternalのパラメーターを解析できない: %v", err)
+		}
+		f.mu.Lock()
+		f.complete = r.PostForm
+		f.mu.Unlock()
+		writeFakeJSON(w, map[string]any{"ok": true, "files": []map[string]string{{"id": "F0001", "title": "ワードクラウド"}}})
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("想定外のAPI呼び出し: %s", r.URL.Path)
+		http.NotFound(w, r)
+	})
+	f.server = httptest.NewServer(mux)
+	t.Cleanup(f.server.Close)
+	return f
+}
+
+func (f *fakeUpload) record(path string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, path)
+}
+
+// client は偽のサーバーを使うクライアントを返す
+func (f *fakeUpload) client() *Client {
+	return NewClient(ClientConfig{Token: "xoxb-test", RateLimit: time.Millisecond, APIURL: f.server.URL + "/api/"})
+}
+
+func writeFakeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func TestUploadFile(t *testing.T) {
+	fake := newFakeUpload(t)
+	data := []byte("\x89PNG\r\n\x1a\nwordcloud")
+	path := filepath.Join(t.TempDir(), "wordcloud.png")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	uploaded, err := fake.client().UploadFile("C0123456789", path,
+		WithTitle("ワードクラウド"),
+		WithComment("*ワードクラウド*\nメッセージ数: 3"),
+		WithThread("1767225600.000100"),
+	)
+	if err != nil {
+		t.Fatalf("UploadFile() error = %v", err)
+	}
+	if uploaded.ID != "F0001" || uploaded.Title != "ワードクラウド" {
+		t.Errorf("UploadFile() = %+v", uploaded)
+	}
+
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	wantCalls := []string{"/api/files.getUploadURLExternal", "/upload/F0001", "/api/files.completeUploadExternal"}
+	if len(fake.calls) != len(wantCalls) {
+		t.Fatalf("呼び出し = %v, want %v", fake.calls, wantCalls)
+	}
+	for i := range wantCalls {
+		if fake.calls[i] != wantCalls[i] {
+			t.Fatalf("呼び出し = %v, want %v", fake.calls, wantCalls)
+		}
+	}
+	if string(fake.file) != string(data) {
+		t.Errorf("アップロードされたファイル = %q, want %q", fake.file, data)
+	}
+	for key, want := range map[string]string{
+		"channel_id":      "C0123456789",
+		"initial_comment": "*ワードクラウド*\nメッセージ数: 3",
+		"thread_ts":       "1767225600.000100",
+	} {
+		if got := fake.complete.Get(key); got != want {
+			t.Errorf("%s = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestUploadFileEmpty(t *testing.T) {
+	fake := newFakeUpload(t)
+	path := filepath.Join(t.TempDir(), "wordcloud.png")
+	if err := os.WriteFile(path, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := fake.client().UploadFile("C0123456789", path); err == nil {
+		t.Fatal("空のファイルのアップロードがエラーにならない")
+	}
+	if len(fake.calls) != 0 {
+		t.Errorf("空のファイルでAPIが呼び出された: %v", fake.calls)
+	}
+}
@@ -1,6 +1,7 @@
 package wordcloud
 
 import (
+	"regexp"
 	"strings"
 	"sync"
 
@@ -10,12 +11,16 @@ import (
 
 // Analyzer は形態素解析を行う構造体
 type Analyzer struct {
-	tokenizer *tokenizer.Tokenizer
-	stopWords map[string]bool
-	mu        sync.Mutex
+	tokenizer    *tokenizer.Tokenizer
+	stopWords    map[string]bool
+	stopPrefixes []string
+	stopPatterns []*regexp.Regexp
+	normalizer   Normalizer
+	mu           sync.Mutex
 }
 
 // NewAnalyzer は新しいAnalyzerを作成
+// 正規化処理は既定では行わない（WithNormalizers(DefaultNormalizers()...)などで指定する）
 func NewAnalyzer(options ...Option) (*Analyzer, error) {
 	t, err := tokenizer.New(ipa.Dict(), tokenizer.OmitBosEos())
 	if err != nil {
@@ -37,6 +42,30 @@ func NewAnalyzer(options ...Option) (*Analyzer, error) {
 
 // Analyze はテキストを解析して単語のスライスを返す
 func (a *Analyzer) Analyze(text string) []Token {
+	return a.tokenize(a.normalize(text))
+}
+
+// analyzeSentences はテキストを正規化してから文に分割し、文ごとの単語のスライスを返す
+// 正規化はテキスト全体に1回だけ行うため、複数行のコードブロックやSlackの記法（<!here>など）が
+// 文の区切りとみなす文字で分断されずに取り除かれる
+func (a *Analyzer) analyzeSentences(text string) [][]Token {
+	var sentences [][]Token
+	for _, sentence := range splitSentences(a.normalize(text)) {
+		sentences = append(sentences, a.tokenize(sentence))
+	}
+	return sentences
+}
+
+// normalize は正規化処理が設定されていればテキストに適用する
+func (a *Analyzer) normalize(text string) string {
+	if a.normalizer != nil {
+		return a.normalizer.Normalize(text)
+	}
+	return text
+}
+
+// tokenize は正規化済みのテキストを形態素解析し、集計対象の単語を返す
+func (a *Analyzer) tokenize(text string) []Token {
 	a.mu.Lock()
 	tokens := a.tokenizer.Tokenize(text)
 	a.mu.Unlock()
@@ -50,6 +79,10 @@ func (a *Analyzer) Analyze(text string) []Token {
 
 		pos := features[0]      // 品詞
 		baseForm := features[6] // 基本形
+		if baseForm == "*" {
+			// 辞書にない単語は基本形を持たないので表層形を使う
+			baseForm = t.Surface
+		}
 
 		// 絵文字やスラックの特殊表記を処理
 		if strings.HasPrefix(baseForm, ":") && strings.HasSuffix(baseForm, ":") {
@@ -57,6 +90,8 @@ func (a *Analyzer) Analyze(text string) []Token {
 				Surface:  baseForm,
 				BaseForm: baseForm,
 				POS:      "絵文字",
+				Start:    t.Start,
+				End:      t.End,
 			})
 			continue
 		}
@@ -72,6 +107,8 @@ func (a *Analyzer) Analyze(text string) []Token {
 				Surface:  "某メンバー",
 				BaseForm: "某メンバー",
 				POS:      "固有名詞",
+				Start:    t.Start,
+				End:      t.End,
 			})
 			continue
 		}
@@ -81,6 +118,8 @@ func (a *Analyzer) Analyze(text string) []Token {
 				Surface:  t.Surface,
 				BaseForm: baseForm,
 				POS:      pos,
+				Start:    t.Start,
+				End:      t.End,
 			})
 		}
 	}
@@ -88,11 +127,47 @@ func (a *Analyzer) Analyze(text string) []Token {
 	return results
 }
 
+// Morphemes はテキストを正規化して形態素解析し、品詞やストップワードで絞り込まずに返す
+// 否定表現など、集計対象外の形態素も必要な処理で使用する
+func (a *Analyzer) Morphemes(text string) []Token {
+	text = a.normalize(text)
+
+	a.mu.Lock()
+	tokens := a.tokenizer.Tokenize(text)
+	a.mu.Unlock()
+
+	results := make([]Token, 0, len(tokens))
+	for _, t := range tokens {
+		token := Token{Surface: t.Surface, BaseForm: t.Surface, Start: t.Start, End: t.End}
+		if features := t.Features(); len(features) >= 7 {
+			token.POS = features[0]
+			if features[6] != "*" {
+				token.BaseForm = features[6]
+			}
+		}
+		results = append(results, token)
+	}
+	return results
+}
+
 // isStopWord は単語がストップワードかどうかを判定
 func (a *Analyzer) isStopWord(word string) bool {
 	a.mu.Lock()
 	defer a.mu.Unlock()
-	return a.stopWords[word]
+	if a.stopWords[word] {
+		return true
+	}
+	for _, prefix := range a.stopPrefixes {
+		if strings.HasPrefix(word, prefix) {
+			return true
+		}
+	}
+	for _, re := range a.stopPatterns {
+		if re.MatchString(word) {
+			return true
+		}
+	}
+	return false
 }
 
 // isTargetPOS は品詞が対象かどうかを判定
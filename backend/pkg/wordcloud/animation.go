@@ -0,0 +1,313 @@
+package wordcloud
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fogleman/gg"
+	"github.com/golang/freetype/truetype"
+)
+
+// アニメーションの出力形式
+const (
+	AnimationGIF  = "gif"
+	AnimationAPNG = "apng"
+)
+
+// AnimationOptions はアニメーションの設定
+type AnimationOptions struct {
+	Format      string        // 出力形式（gif/apng）
+	FrameDelay  time.Duration // 1フレームの表示時間
+	TweenFrames int           // 期間と期間の間に挿入する補間フレーム数
+	HoldFrames  int           // 各期間の状態を表示し続ける時間（FrameDelayの何フレーム分か）
+}
+
+// DefaultAnimationOptions はデフォルトのアニメーション設定を返す
+func DefaultAnimationOptions() AnimationOptions {
+	return AnimationOptions{
+		Format:      AnimationGIF,
+		FrameDelay:  80 * time.Millisecond,
+		TweenFrames: 6,
+		HoldFrames:  10,
+	}
+}
+
+// animatedWord は全フレームで位置を固定した単語と期間ごとの大きさ
+type animatedWord struct {
+	text    string
+	color   color.RGBA
+	cx, cy  float64   // 配置の中心
+	sizes   []float64 // 期間ごとのフォントサイズ（出現しない期間は0）
+	opacity []float64 // 期間ごとの不透明度
+}
+
+// ExportAnimation は単語の推移を期間ごとのワードクラウドのアニメーションとして出力
+// 単語の位置は全期間で最も大きく表示される大きさで一度だけ決めるため、フレーム間で動かない
+func (fp *FileProcessor) ExportAnimation(t *Trends, outputPath string, opts AnimationOptions) error {
+	if len(t.Buckets) == 0 || len(t.Series) == 0 {
+		return fmt.Errorf("アニメーションにする単語がありません")
+	}
+	if opts.Format != AnimationGIF && opts.Format != AnimationAPNG {
+		return fmt.Errorf("不明なアニメーション形式です: %s", opts.Format)
+	}
+
+	font, err := fp.loadFont()
+	if err != nil {
+		return err
+	}
+
+	// 全期間を通した最大出現回数を基準にフォントサイズを決める
+	maxCount := 1
+	for _, s := range t.Series {
+		for _, c := range s.Counts {
+			maxCount = max(maxCount, c)
+		}
+	}
+	sizeOf := func(count int) float64 {
+		return float64(fp.generator.linearFontSize(math.Sqrt(float64(count) / float64(maxCount))))
+	}
+
+	// 最大の大きさで配置を決める
+	const headerHeight = 28.0
+	width, height := float64(fp.config.Width), float64(fp.config.Height)
+	layout := make([]WordCount, len(t.Series))
+	for i, s := range t.Series {
+		peak := 0
+		for _, c := range s.Counts {
+			peak = max(peak, c)
+		}
+		layout[i] = WordCount{Text: s.Word, Count: s.Total, FontSize: int(sizeOf(peak))}
+	}
+	dc := gg.NewContext(fp.config.Width, fp.config.Height)
+	placed := placeWords(dc, font, layout, Rectangle{Y: headerHeight, W: width, H: height - headerHeight})
+	fp.warnUnplaced(layout, placed)
+
+	seriesOf := make(map[string]TrendSeries, len(t.Series))
+	for _, s := range t.Series {
+		seriesOf[s.Word] = s
+	}
+	placedSeries := make([]TrendSeries, len(placed))
+	for i, p := range placed {
+		placedSeries[i] = seriesOf[p.Text]
+	}
+	colors, err := fp.generator.seriesColors(trendSeriesWords(placedSeries))
+	if err != nil {
+		return err
+	}
+	words := make([]animatedWord, len(placed))
+	for i, p := range placed {
+		s := placedSeries[i]
+		w := animatedWord{
+			text:    p.Text,
+			color:   parseHexColor(colors[i]),
+			cx:      p.X + p.W/2,
+			cy:      p.Y - p.H/2,
+			sizes:   make([]float64, len(s.Counts)),
+			opacity: make([]float64, len(s.Counts)),
+		}
+		for k, c := range s.Counts {
+			if c > 0 {
+				w.sizes[k] = sizeOf(c)
+				w.opacity[k] = 1
+			}
+		}
+		words[i] = w
+	}
+
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		return fmt.Errorf("出力ディレクトリの作成に失敗: %w", err)
+	}
+	file, err := os.Create(outputPath)
+	if err != nil {
+		return fmt.Errorf("出力ファイルの作成に失敗: %w", err)
+	}
+	defer file.Close()
+
+	// 各期間の状態は1枚のフレームを保持フレーム数分の時間だけ表示し、次の期間への補間フレームを続ける
+	// フレームは描画するたびにエンコーダーに渡し、すべてをメモリに保持しない
+	frames := len(t.Buckets) + (len(t.Buckets)-1)*max(opts.TweenFrames, 0)
+	var enc animationEncoder
+	switch opts.Format {
+	case AnimationAPNG:
+		enc = newAPNGEncoder(file, frames)
+	default:
+		enc = newGIFEncoder(file, gifPalette(fp.config, words))
+	}
+	hold := opts.FrameDelay * time.Duration(max(opts.HoldFrames, 1))
+	for k := range t.Buckets {
+		label := t.bucketLabel(k)
+		if err := enc.WriteFrame(renderAnimationFrame(fp.config, font, words, k, k, 0, label), hold); err != nil {
+			return fmt.Errorf("アニメーションのエンコードに失敗: %w", err)
+		}
+		if k == len(t.Buckets)-1 {
+			break
+		}
+		for f := 1; f <= opts.TweenFrames; f++ {
+			ratio := float64(f) / float64(opts.TweenFrames+1)
+			if err := enc.WriteFrame(renderAnimationFrame(fp.config, font, words, k, k+1, ratio, label), opts.FrameDelay); err != nil {
+				return fmt.Errorf("アニメーションのエンコードに失敗: %w", err)
+			}
+		}
+	}
+	if err := enc.Close(); err != nil {
+		return fmt.Errorf("アニメーションのエンコードに失敗: %w", err)
+	}
+
+	fp.generator.logger.Info("アニメーションを出力しました", "path", outputPath, "frames", frames)
+	return nil
+}
+
+// renderAnimationFrame は期間fromから期間toへratioだけ進んだ状態のフレームを描画
+func renderAnimationFrame(config Config, font *truetype.Font, words []animatedWord, from, to int, ratio float64, label string) image.Image {
+	dc := gg.NewContext(config.Width, config.Height)
+	dc.SetColor(parseHexColor(config.background()))
+	dc.Clear()
+
+	dc.SetFontFace(newFace(font, 14))
+	dc.SetHexColor(config.textColor())
+	dc.DrawStringAnchored(label, 12, 14, 0, 0.5)
+
+	for _, w := range words {
+		size := lerp(w.sizes[from], w.sizes[to], ratio)
+		opacity := lerp(w.opacity[from], w.opacity[to], ratio)
+		if opacity <= 0 {
+			continue
+		}
+		if size <= 0 {
+			// 出現しない期間に向けて消えていく単語は大きさを保ったまま薄くする
+			size = math.Max(w.sizes[from], w.sizes[to])
+		}
+		dc.SetFontFace(newFace(font, size))
+		dc.SetRGBA255(int(w.color.R), int(w.color.G), int(w.color.B), int(255*opacity))
+		dc.DrawStringAnchored(w.text, w.cx, w.cy, 0.5, 0.5)
+	}
+	return dc.Image()
+}
+
+// lerp は線形補間
+func lerp(a, b, ratio float64) float64 {
+	return a + (b-a)*ratio
+}
+
+// gifBlendLevels はGIFのパレットで各色に用意する背景色との中間色の段階数
+const gifBlendLevels = 16
+
+// animationEncoder はアニメーションのフレームを1枚ずつ書き込むエンコーダー
+// フレームごとに表示時間を指定できる
+type animationEncoder interface {
+	WriteFrame(frame image.Image, delay time.Duration) error
+	Close() error
+}
+
+// gifPalette は見出しと単語の色を背景色と混ぜた中間色で構成したGIFのパレットを返す
+// 中間色でアンチエイリアスや半透明を表現する
+func gifPalette(config Config, words []animatedWord) color.Palette {
+	bg := parseHexColor(config.background())
+	base := []color.RGBA{parseHexColor(config.textColor())}
+	seen := map[color.RGBA]bool{base[0]: true}
+	for _, word := range words {
+		if !seen[word.color] {
+			seen[word.color] = true
+			base = append(base, word.color)
+		}
+	}
+
+	p := color.Palette{bg}
+	levels := min(gifBlendLevels, 255/len(base))
+	for _, c := range base {
+		for l := 1; l <= levels; l++ {
+			p = append(p, mixColor(bg, c, float64(l)/float64(levels)))
+		}
+	}
+	return p
+}
+
+// gifLoopExtension は無限に繰り返し再生するNETSCAPE2.0アプリケーション拡張
+var gifLoopExtension = []byte{0x21, 0xff, 0x0b, 'N', 'E', 'T', 'S', 'C', 'A', 'P', 'E', '2', '.', '0', 0x03, 0x01, 0x00, 0x00, 0x00}
+
+// gifTrailer はGIFの終端
+const gifTrailer = 0x3b
+
+// gifEncoder はフレームを1枚ずつアニメーションGIFとして書き込む
+// 各フレームを標準ライブラリのimage/gifで1枚のGIFとしてエンコードし、2枚目以降はヘッダーを除いた画像のブロックだけを続ける
+type gifEncoder struct {
+	w       io.Writer
+	palette color.Palette // 全フレームで共通のパレット
+	count   int           // 書き込んだフレーム数
+}
+
+// newGIFEncoder は全フレームで共通のパレットを使うgifEncoderを作成
+func newGIFEncoder(w io.Writer, palette color.Palette) *gifEncoder {
+	return &gifEncoder{w: w, palette: palette}
+}
+
+// WriteFrame はフレームをパレットの色に変換し、delayの表示時間で書き込む
+func (e *gifEncoder) WriteFrame(frame image.Image, delay time.Duration) error {
+	bounds := frame.Bounds()
+	paletted := image.NewPaletted(bounds, e.palette)
+	draw.Draw(paletted, paletted.Rect, frame, bounds.Min, draw.Src)
+
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, &gif.GIF{
+		Image:  []*image.Paletted{paletted},
+		Delay:  []int{int(delay / (10 * time.Millisecond))},
+		Config: image.Config{ColorModel: e.palette, Width: bounds.Dx(), Height: bounds.Dy()},
+	}); err != nil {
+		return fmt.Errorf("%d枚目のフレームのエンコードに失敗: %w", e.count+1, err)
+	}
+	b := buf.Bytes()
+	header, err := gifHeaderLength(b)
+	if err != nil {
+		return fmt.Errorf("%d枚目のフレームの解析に失敗: %w", e.count+1, err)
+	}
+
+	if e.count == 0 {
+		// ヘッダーと共通のパレットに続けて、繰り返し再生の拡張を書き込む
+		if _, err := e.w.Write(b[:header]); err != nil {
+			return err
+		}
+		if _, err := e.w.Write(gifLoopExtension); err != nil {
+			return err
+		}
+	}
+	if _, err := e.w.Write(b[header : len(b)-1]); err != nil {
+		return err
+	}
+	e.count++
+	return nil
+}
+
+// Close は終端を書き込んでGIFを完成させる
+func (e *gifEncoder) Close() error {
+	if e.count == 0 {
+		return errors.New("フレームがありません")
+	}
+	_, err := e.w.Write([]byte{gifTrailer})
+	return err
+}
+
+// gifHeaderLength は1枚のGIFのヘッダー（論理画面記述子と共通のパレットまで）の長さを返す
+func gifHeaderLength(b []byte) (int, error) {
+	const screenDescriptorEnd = 13
+	if len(b) < screenDescriptorEnd+1 || b[len(b)-1] != gifTrailer {
+		return 0, errors.New("GIFの形式が不正です")
+	}
+	n := screenDescriptorEnd
+	if flags := b[10]; flags&0x80 != 0 {
+		n += 3 << ((flags & 0x07) + 1)
+	}
+	if n >= len(b) {
+		return 0, errors.New("GIFのパレットが途中で終わっています")
+	}
+	return n, nil
+}
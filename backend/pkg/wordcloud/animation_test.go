@@ -0,0 +1,134 @@
+package wordcloud
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image/gif"
+	"image/png"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+// writeTestFont はテスト用のTrueTypeフォントを一時ディレクトリに書き込んでパスを返す
+func writeTestFont(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "Go-Regular.ttf")
+	if err := os.WriteFile(path, goregular.TTF, 0644); err != nil {
+		t.Fatalf("フォントの書き込みに失敗: %v", err)
+	}
+	return path
+}
+
+// newTestFileProcessor は小さな画像を出力するFileProcessorを作成する（modifyで設定を変更できる）
+func newTestFileProcessor(t *testing.T, modify ...func(*Config)) *FileProcessor {
+	t.Helper()
+	config := defaultConfig()
+	config.Width = 160
+	config.Height = 120
+	config.FontPath = writeTestFont(t)
+	for _, m := range modify {
+		m(&config)
+	}
+	processor, err := NewFileProcessor(config, newTestAnalyzer(t))
+	if err != nil {
+		t.Fatalf("プロセッサーの初期化に失敗: %v", err)
+	}
+	return processor
+}
+
+// animationTrends は3つの期間の推移
+func animationTrends() *Trends {
+	return &Trends{
+		Period: PeriodDay,
+		Buckets: []time.Time{
+			time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+			time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC),
+			time.Date(2026, 1, 3, 0, 0, 0, 0, time.UTC),
+		},
+		Totals: []int{5, 3, 4},
+		Series: []TrendSeries{
+			{Word: "deploy", Counts: []int{4, 0, 2}, Total: 6},
+			{Word: "review", Counts: []int{1, 3, 2}, Total: 6},
+		},
+	}
+}
+
+// animationOptions は保持フレームを5枚分、補間フレームを2枚とする設定
+func animationOptions(format string) AnimationOptions {
+	return AnimationOptions{Format: format, FrameDelay: 80 * time.Millisecond, TweenFrames: 2, HoldFrames: 5}
+}
+
+func TestExportAnimationGIF(t *testing.T) {
+	processor := newTestFileProcessor(t)
+	output := filepath.Join(t.TempDir(), "trends.gif")
+	if err := processor.ExportAnimation(animationTrends(), output, animationOptions(AnimationGIF)); err != nil {
+		t.Fatalf("ExportAnimation() error = %v", err)
+	}
+
+	file, err := os.Open(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	anim, err := gif.DecodeAll(file)
+	if err != nil {
+		t.Fatalf("出力したGIFを読み込めない: %v", err)
+	}
+
+	// 保持する状態は1枚のフレームを5枚分の時間だけ表示する
+	want := []int{40, 8, 8, 40, 8, 8, 40}
+	if !slices.Equal(anim.Delay, want) {
+		t.Errorf("フレームの表示時間 = %v, want %v", anim.Delay, want)
+	}
+	if anim.LoopCount != 0 {
+		t.Errorf("LoopCount = %d, want 0（無限）", anim.LoopCount)
+	}
+	if anim.Config.Width != 160 || anim.Config.Height != 120 {
+		t.Errorf("画像のサイズが %dx%d", anim.Config.Width, anim.Config.Height)
+	}
+}
+
+func TestExportAnimationAPNG(t *testing.T) {
+	processor := newTestFileProcessor(t)
+	output := filepath.Join(t.TempDir(), "trends.png")
+	if err := processor.ExportAnimation(animationTrends(), output, animationOptions(AnimationAPNG)); err != nil {
+		t.Fatalf("ExportAnimation() error = %v", err)
+	}
+
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chunks, err := readPNGChunks(data)
+	if err != nil {
+		t.Fatalf("出力したAPNGを解析できない: %v", err)
+	}
+
+	var frames uint32
+	var delays []uint16
+	for _, c := range chunks {
+		switch c.typ {
+		case "acTL":
+			frames = binary.BigEndian.Uint32(c.data[0:4])
+		case "fcTL":
+			delays = append(delays, binary.BigEndian.Uint16(c.data[20:22]))
+		}
+	}
+	want := []uint16{400, 80, 80, 400, 80, 80, 400}
+	if int(frames) != len(want) || !slices.Equal(delays, want) {
+		t.Errorf("acTLのフレーム数 = %d、表示時間 = %v, want %d, %v", frames, delays, len(want), want)
+	}
+	if last := chunks[len(chunks)-1]; last.typ != "IEND" {
+		t.Errorf("最後のチャンクが %s", last.typ)
+	}
+
+	// APNG非対応のビューアでは1枚目のフレームを表示できる
+	if _, err := png.Decode(bytes.NewReader(data)); err != nil {
+		t.Errorf("1枚目のフレームをPNGとして読み込めない: %v", err)
+	}
+}
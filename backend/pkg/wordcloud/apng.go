@@ -0,0 +1,175 @@
+package wordcloud
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/png"
+	"io"
+	"time"
+)
+
+// pngSignature はPNGファイルの先頭8バイト
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// pngChunk はPNGのチャンク
+type pngChunk struct {
+	typ  string
+	data []byte
+}
+
+// readPNGChunks はPNGのバイト列をチャンクに分割
+func readPNGChunks(b []byte) ([]pngChunk, error) {
+	if !bytes.HasPrefix(b, pngSignature) {
+		return nil, errors.New("PNGのシグネチャが不正です")
+	}
+	b = b[len(pngSignature):]
+
+	var chunks []pngChunk
+	for len(b) >= 12 {
+		length := binary.BigEndian.Uint32(b[:4])
+		if uint64(len(b)) < 12+uint64(length) {
+			return nil, errors.New("PNGのチャンクが途中で終わっています")
+		}
+		chunks = append(chunks, pngChunk{
+			typ:  string(b[4:8]),
+			data: b[8 : 8+length],
+		})
+		b = b[12+length:]
+	}
+	return chunks, nil
+}
+
+// writePNGChunk はCRC付きのチャンクを書き込む
+func writePNGChunk(w io.Writer, typ string, data []byte) error {
+	var header [8]byte
+	binary.BigEndian.PutUint32(header[:4], uint32(len(data)))
+	copy(header[4:], typ)
+
+	crc := crc32.NewIEEE()
+	crc.Write(header[4:])
+	crc.Write(data)
+
+	var footer [4]byte
+	binary.BigEndian.PutUint32(footer[:], crc.Sum32())
+
+	for _, b := range [][]byte{header[:], data, footer[:]} {
+		if _, err := w.Write(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// apngEncoder はフレームを1枚ずつアニメーションPNG（APNG）として書き込む
+// 各フレームを標準ライブラリのimage/pngでエンコードし、画像データをfdATチャンクに詰め替える
+// すべてのフレームは同じ大きさで、無限に繰り返し再生される
+type apngEncoder struct {
+	w      io.Writer
+	frames int    // acTLに書き込むフレーム数
+	ihdr   []byte // 1枚目のフレームのIHDR
+	seq    uint32 // fcTL・fdATチャンクの通し番号
+	count  int    // 書き込んだフレーム数
+}
+
+// newAPNGEncoder はframes枚のフレームを書き込むapngEncoderを作成
+// APNGは先頭にフレーム数を書き込むため、あらかじめ枚数を指定する
+func newAPNGEncoder(w io.Writer, frames int) *apngEncoder {
+	return &apngEncoder{w: w, frames: frames}
+}
+
+// WriteFrame はフレームをdelayの表示時間で書き込む
+func (e *apngEncoder) WriteFrame(frame image.Image, delay time.Duration) error {
+	if e.count >= e.frames {
+		return fmt.Errorf("フレームが予定の%d枚を超えています", e.frames)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, frame); err != nil {
+		return fmt.Errorf("%d枚目のフレームのエンコードに失敗: %w", e.count+1, err)
+	}
+	chunks, err := readPNGChunks(buf.Bytes())
+	if err != nil {
+		return fmt.Errorf("%d枚目のフレームの解析に失敗: %w", e.count+1, err)
+	}
+	var ihdr []byte
+	var data [][]byte
+	for _, c := range chunks {
+		switch c.typ {
+		case "IHDR":
+			ihdr = c.data
+		case "IDAT":
+			data = append(data, c.data)
+		}
+	}
+
+	if e.count == 0 {
+		if err := e.writeHeader(ihdr); err != nil {
+			return err
+		}
+	} else if !bytes.Equal(e.ihdr, ihdr) {
+		return fmt.Errorf("%d枚目のフレームの形式が1枚目と異なります", e.count+1)
+	}
+
+	// fcTL: フレームの大きさ・位置・表示時間
+	bounds := frame.Bounds()
+	fctl := make([]byte, 26)
+	binary.BigEndian.PutUint32(fctl[0:4], e.seq)
+	binary.BigEndian.PutUint32(fctl[4:8], uint32(bounds.Dx()))
+	binary.BigEndian.PutUint32(fctl[8:12], uint32(bounds.Dy()))
+	binary.BigEndian.PutUint16(fctl[20:22], uint16(min(delay.Milliseconds(), 65535)))
+	binary.BigEndian.PutUint16(fctl[22:24], 1000)
+	e.seq++
+	if err := writePNGChunk(e.w, "fcTL", fctl); err != nil {
+		return err
+	}
+
+	for _, d := range data {
+		if e.count == 0 {
+			// 1枚目はAPNG非対応のビューアでも表示できるようIDATとして書き込む
+			if err := writePNGChunk(e.w, "IDAT", d); err != nil {
+				return err
+			}
+			continue
+		}
+		fdat := make([]byte, 4+len(d))
+		binary.BigEndian.PutUint32(fdat[0:4], e.seq)
+		copy(fdat[4:], d)
+		e.seq++
+		if err := writePNGChunk(e.w, "fdAT", fdat); err != nil {
+			return err
+		}
+	}
+	e.count++
+	return nil
+}
+
+// writeHeader はシグネチャ・IHDR・acTLチャンクを書き込む
+func (e *apngEncoder) writeHeader(ihdr []byte) error {
+	e.ihdr = ihdr
+	if _, err := e.w.Write(pngSignature); err != nil {
+		return err
+	}
+	if err := writePNGChunk(e.w, "IHDR", ihdr); err != nil {
+		return err
+	}
+
+	// acTL: フレーム数と繰り返し回数（0は無限）
+	actl := make([]byte, 8)
+	binary.BigEndian.PutUint32(actl[0:4], uint32(e.frames))
+	return writePNGChunk(e.w, "acTL", actl)
+}
+
+// Close はIENDチャンクを書き込んでAPNGを完成させる
+func (e *apngEncoder) Close() error {
+	if e.count == 0 {
+		return errors.New("フレームがありません")
+	}
+	if e.count != e.frames {
+		return fmt.Errorf("書き込んだフレームが%d枚で、予定の%d枚と異なります", e.count, e.frames)
+	}
+	return writePNGChunk(e.w, "IEND", nil)
+}
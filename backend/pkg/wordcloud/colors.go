@@ -0,0 +1,434 @@
+package wordcloud
+
+import (
+	"bufio"
+	"fmt"
+	"hash/fnv"
+	"image/color"
+	"io"
+	"math"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// 組み込みの色スキーム
+const (
+	ColorBlue        = "blue"        // 値が大きいほど濃い青
+	ColorRainbow     = "rainbow"     // 値に応じて赤から青へ色相を変える
+	ColorSequential  = "sequential"  // 値に応じてパレットの色を順に補間
+	ColorDiverging   = "diverging"   // 負・中立・正の値をパレットの両端と中央に割り当て
+	ColorCategorical = "categorical" // 順位ごとにパレットの色を順に割り当て
+	ColorViridis     = "viridis"     // 値に応じてViridis風の色を補間
+	ColorRandom      = "random"      // 単語ごとにパレットから選択（同じ単語は常に同じ色）
+	ColorByPOS       = "pos"         // 品詞ごとにパレットの色を割り当て
+	ColorByUser      = "user"        // 最も多く使ったユーザーごとにパレットの色を割り当て
+)
+
+// ColorContext は単語の色を決めるために計算した値
+type ColorContext struct {
+	Ratio  float64 // 最大値に対する値の比（0〜1）
+	Signed float64 // 符号付きの値（-1〜1。感情スコアや対数尤度比の向きなど）
+	Rank   int     // 値の大きい順の順位（0始まり）
+}
+
+// ColorScheme は単語の色を決める配色
+type ColorScheme interface {
+	// Color は単語の色を "#RRGGBB" 形式で返す
+	Color(word WordCount, ctx ColorContext) string
+}
+
+// ColorSchemeFunc は関数をColorSchemeとして使うための型
+type ColorSchemeFunc func(word WordCount, ctx ColorContext) string
+
+// Color は関数を呼び出して色を返す
+func (f ColorSchemeFunc) Color(word WordCount, ctx ColorContext) string {
+	return f(word, ctx)
+}
+
+// Palette は配色に使う "#RRGGBB" 形式の色の列
+type Palette []string
+
+// 組み込みのパレット
+var namedPalettes = map[string]Palette{
+	"blues":     {"#9ECAE1", "#6BAED6", "#4292C6", "#2171B5", "#08519C", "#08306B"},
+	"viridis":   {"#440154", "#482878", "#3E4A89", "#31688E", "#26828E", "#1F9E89", "#35B779", "#6DCD59", "#B4DE2C", "#FDE725"},
+	"rdylgn":    {"#D62728", "#9E9E9E", "#2CA02C"},
+	"rdbu":      {"#B2182B", "#D6604D", "#BABABA", "#4393C3", "#2166AC"},
+	"tableau10": {"#1F77B4", "#FF7F0E", "#2CA02C", "#D62728", "#9467BD", "#8C564B", "#E377C2", "#7F7F7F", "#BCBD22", "#17BECF"},
+	"set2":      {"#66C2A5", "#FC8D62", "#8DA0CB", "#E78AC3", "#A6D854", "#FFD92F", "#E5C494", "#B3B3B3"},
+}
+
+// PaletteNames は組み込みのパレット名を返す
+func PaletteNames() []string {
+	names := make([]string, 0, len(namedPalettes))
+	for name := range namedPalettes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// palettePresetPrefix は組み込みのパレットを指定する接頭辞
+const palettePresetPrefix = "preset:"
+
+// LoadPalette はパレットを読み込む
+// "preset:NAME" は組み込みのパレット、"#" で始まるかカンマを含む指定は色の列、それ以外はファイルとして扱う
+func LoadPalette(spec string) (Palette, error) {
+	spec = strings.TrimSpace(spec)
+	switch {
+	case spec == "":
+		return nil, nil
+	case strings.HasPrefix(spec, palettePresetPrefix):
+		name := strings.TrimPrefix(spec, palettePresetPrefix)
+		palette, ok := namedPalettes[name]
+		if !ok {
+			return nil, fmt.Errorf("不明なパレットです: %s（%s）", name, strings.Join(PaletteNames(), ", "))
+		}
+		return append(Palette(nil), palette...), nil
+	case strings.HasPrefix(spec, "#") || strings.Contains(spec, ","):
+		return ParsePalette(strings.NewReader(strings.ReplaceAll(spec, ",", "\n")))
+	}
+
+	file, err := os.Open(spec)
+	if err != nil {
+		return nil, fmt.Errorf("パレットファイルのオープンに失敗: %w", err)
+	}
+	defer file.Close()
+
+	palette, err := ParsePalette(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", spec, err)
+	}
+	return palette, nil
+}
+
+// ParsePalette は1行に1色を記述したテキストからパレットを読み込む
+// 色は "#RRGGBB"・"#RGB"・"hsl(h, s%, l%)" 形式で指定し、色として解釈できない "#" で始まる行はコメントとして扱う
+func ParsePalette(r io.Reader) (Palette, error) {
+	var palette Palette
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		c, ok := parseColor(line)
+		if !ok {
+			if strings.HasPrefix(line, "#") {
+				continue
+			}
+			return nil, fmt.Errorf("%d行目の色を解析できません: %q", lineNo, line)
+		}
+		palette = append(palette, hexColor(c))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("パレットの読み込みに失敗: %w", err)
+	}
+	if len(palette) == 0 {
+		return nil, fmt.Errorf("パレットに色がありません")
+	}
+	return palette, nil
+}
+
+// NewColorScheme は名前とパレットから組み込みの色スキームを作成
+// パレットが空の場合は色スキームごとの既定のパレットを使う
+func NewColorScheme(name string, palette Palette) (ColorScheme, error) {
+	pick := func(defaultName string) []color.RGBA {
+		if len(palette) == 0 {
+			palette = namedPalettes[defaultName]
+		}
+		colors := make([]color.RGBA, len(palette))
+		for i, s := range palette {
+			colors[i], _ = parseColor(s)
+		}
+		return colors
+	}
+
+	switch name {
+	case ColorBlue, "":
+		colors := []color.RGBA{{0, 0, 150, 0xff}, {0, 0, 255, 0xff}}
+		return ColorSchemeFunc(func(_ WordCount, ctx ColorContext) string {
+			return hexColor(gradient(colors, ctx.Ratio))
+		}), nil
+	case ColorRainbow:
+		return ColorSchemeFunc(func(_ WordCount, ctx ColorContext) string {
+			return hexColor(hslColor(240*ctx.Ratio, 0.7, 0.5))
+		}), nil
+	case ColorSequential:
+		colors := pick("blues")
+		return ColorSchemeFunc(func(_ WordCount, ctx ColorContext) string {
+			return hexColor(gradient(colors, ctx.Ratio))
+		}), nil
+	case ColorViridis:
+		colors := pick("viridis")
+		return ColorSchemeFunc(func(_ WordCount, ctx ColorContext) string {
+			return hexColor(gradient(colors, ctx.Ratio))
+		}), nil
+	case ColorDiverging, ColorSentiment:
+		colors := pick("rdylgn")
+		return ColorSchemeFunc(func(_ WordCount, ctx ColorContext) string {
+			return hexColor(gradient(colors, (ctx.Signed+1)/2))
+		}), nil
+	case ColorCategorical:
+		colors := pick("tableau10")
+		return ColorSchemeFunc(func(_ WordCount, ctx ColorContext) string {
+			return hexColor(colors[ctx.Rank%len(colors)])
+		}), nil
+	case ColorRandom:
+		colors := pick("tableau10")
+		return ColorSchemeFunc(func(word WordCount, _ ColorContext) string {
+			return hexColor(colors[hashIndex(word.Text, len(colors))])
+		}), nil
+	case ColorByPOS:
+		colors := pick("tableau10")
+		return ColorSchemeFunc(func(word WordCount, _ ColorContext) string {
+			return hexColor(colors[posIndex(word.POS)%len(colors)])
+		}), nil
+	case ColorByUser:
+		colors := pick("tableau10")
+		return ColorSchemeFunc(func(word WordCount, _ ColorContext) string {
+			if word.User == "" {
+				return "#7F7F7F"
+			}
+			return hexColor(colors[hashIndex(word.User, len(colors))])
+		}), nil
+	default:
+		return nil, fmt.Errorf("不明な色スキームです: %s", name)
+	}
+}
+
+// posOrder は品詞ごとの色の順序（含まれない品詞は末尾の色）
+var posOrder = []string{"名詞", "動詞", "形容詞", "固有名詞", "絵文字", PhrasePOS}
+
+// posIndex は品詞のパレット上の位置を返す
+func posIndex(pos string) int {
+	for i, p := range posOrder {
+		if p == pos {
+			return i
+		}
+	}
+	return len(posOrder)
+}
+
+// hashIndex は文字列から0〜n-1の値を決定的に選ぶ
+func hashIndex(s string, n int) int {
+	h := fnv.New32a()
+	h.Write([]byte(s))
+	return int(h.Sum32() % uint32(n))
+}
+
+// gradient はratio（0〜1）の位置の色を等間隔に並んだ色から線形補間で求める
+func gradient(colors []color.RGBA, ratio float64) color.RGBA {
+	if len(colors) == 1 {
+		return colors[0]
+	}
+	ratio = math.Max(0, math.Min(1, ratio))
+	pos := ratio * float64(len(colors)-1)
+	i := min(int(pos), len(colors)-2)
+	return mixColor(colors[i], colors[i+1], pos-float64(i))
+}
+
+// mixColor はaとbをratioの割合で混ぜた色を返す
+func mixColor(a, b color.RGBA, ratio float64) color.RGBA {
+	return color.RGBA{
+		R: uint8(math.Round(lerp(float64(a.R), float64(b.R), ratio))),
+		G: uint8(math.Round(lerp(float64(a.G), float64(b.G), ratio))),
+		B: uint8(math.Round(lerp(float64(a.B), float64(b.B), ratio))),
+		A: 0xff,
+	}
+}
+
+// hslColor は色相（度）・彩度・明度（0〜1）から色を作成
+func hslColor(h, s, l float64) color.RGBA {
+	h = math.Mod(math.Mod(h, 360)+360, 360) / 360
+	hue := func(t float64) float64 {
+		t = math.Mod(t+1, 1)
+		q := l + s - l*s
+		if l < 0.5 {
+			q = l * (1 + s)
+		}
+		p := 2*l - q
+		switch {
+		case t < 1.0/6:
+			return p + (q-p)*6*t
+		case t < 0.5:
+			return q
+		case t < 2.0/3:
+			return p + (q-p)*(2.0/3-t)*6
+		default:
+			return p
+		}
+	}
+	return color.RGBA{
+		R: uint8(math.Round(hue(h+1.0/3) * 255)),
+		G: uint8(math.Round(hue(h) * 255)),
+		B: uint8(math.Round(hue(h-1.0/3) * 255)),
+		A: 0xff,
+	}
+}
+
+// hslPattern は "hsl(h, s%, l%)" 形式の色
+var hslPattern = regexp.MustCompile(`^hsl\(\s*([\d.]+)\s*,\s*([\d.]+)%\s*,\s*([\d.]+)%\s*\)$`)
+
+// parseColor は "#RRGGBB"・"#RGB"・"hsl(h, s%, l%)" 形式の色を解析
+func parseColor(s string) (color.RGBA, bool) {
+	s = strings.TrimSpace(s)
+	if m := hslPattern.FindStringSubmatch(s); m != nil {
+		h, _ := strconv.ParseFloat(m[1], 64)
+		sat, _ := strconv.ParseFloat(m[2], 64)
+		l, _ := strconv.ParseFloat(m[3], 64)
+		return hslColor(h, math.Min(sat, 100)/100, math.Min(l, 100)/100), true
+	}
+
+	hex := strings.TrimPrefix(s, "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil || len(hex) != 6 || !strings.HasPrefix(s, "#") {
+		return color.RGBA{A: 0xff}, false
+	}
+	return color.RGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 0xff}, true
+}
+
+// parseHexColor は色を解析する（解析できない場合は黒）
+func parseHexColor(s string) color.RGBA {
+	c, _ := parseColor(s)
+	return c
+}
+
+// hexColor は色を "#RRGGBB" 形式の文字列にする
+func hexColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02X%02X%02X", c.R, c.G, c.B)
+}
+
+// relativeLuminance はWCAGの相対輝度を返す
+func relativeLuminance(c color.RGBA) float64 {
+	channel := func(v uint8) float64 {
+		x := float64(v) / 255
+		if x <= 0.03928 {
+			return x / 12.92
+		}
+		return math.Pow((x+0.055)/1.055, 2.4)
+	}
+	return 0.2126*channel(c.R) + 0.7152*channel(c.G) + 0.0722*channel(c.B)
+}
+
+// ContrastRatio は2つの色のWCAGのコントラスト比（1〜21）を返す
+func ContrastRatio(a, b string) float64 {
+	la, lb := relativeLuminance(parseHexColor(a)), relativeLuminance(parseHexColor(b))
+	if la < lb {
+		la, lb = lb, la
+	}
+	return (la + 0.05) / (lb + 0.05)
+}
+
+// defaultMinContrast は背景色との最小コントラスト比の既定値（WCAGの大きな文字の基準）
+const defaultMinContrast = 3.0
+
+// ensureContrast は背景色とのコントラスト比がminRatioに満たない色を、
+// 黒または白のうち背景色と対比の大きい方へ近づけて読みやすくする
+func ensureContrast(fg, bg string, minRatio float64) string {
+	if minRatio <= 1 || ContrastRatio(fg, bg) >= minRatio {
+		return fg
+	}
+	target := color.RGBA{A: 0xff}
+	if relativeLuminance(parseHexColor(bg)) < 0.18 {
+		target = color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+	}
+	base := parseHexColor(fg)
+	for step := 1; step <= 20; step++ {
+		adjusted := hexColor(mixColor(base, target, float64(step)/20))
+		if ContrastRatio(adjusted, bg) >= minRatio {
+			return adjusted
+		}
+	}
+	return hexColor(target)
+}
+
+// background は背景色を返す（未指定の場合は白）
+func (c Config) background() string {
+	if c.Background == "" {
+		return "#FFFFFF"
+	}
+	return c.Background
+}
+
+// textColor は見出しや軸などの文字の色を返す（背景色とのコントラストを確認して調整する）
+func (c Config) textColor() string {
+	return ensureContrast(defaultTextColor, c.background(), c.minContrast())
+}
+
+// defaultTextColor は白い背景で見出しや軸に使う文字の色
+const defaultTextColor = "#333333"
+
+// minContrast は背景色との最小コントラスト比を返す（負の値は確認しない）
+func (c Config) minContrast() float64 {
+	if c.MinContrast == 0 {
+		return defaultMinContrast
+	}
+	return c.MinContrast
+}
+
+// colorScheme は設定から色スキームを返す
+func (g *Generator) colorScheme() (ColorScheme, error) {
+	if g.config.Colors != nil {
+		return g.config.Colors, nil
+	}
+	return NewColorScheme(g.config.ColorScheme, g.config.Palette)
+}
+
+// applyColors は値の大きい順に並んだ単語に色スキームの色を設定する
+// 色は背景色とのコントラストを確認して調整する
+func (g *Generator) applyColors(words []WordCount, value func(WordCount) float64) error {
+	scheme, err := g.colorScheme()
+	if err != nil {
+		return err
+	}
+
+	maxValue, maxAbs := 0.0, 0.0
+	for _, w := range words {
+		maxValue = math.Max(maxValue, value(w))
+		maxAbs = math.Max(maxAbs, math.Abs(w.Score))
+	}
+
+	useSentiment := g.config.sentimentLexicon() != nil
+	signedScore := g.config.Weighting == WeightingLogLikelihood && maxAbs > 0
+	bg := g.config.background()
+	for i := range words {
+		ctx := ColorContext{Rank: i}
+		if maxValue > 0 {
+			ctx.Ratio = math.Max(0, math.Min(1, value(words[i])/maxValue))
+		}
+		switch {
+		case useSentiment:
+			ctx.Signed = words[i].Sentiment
+		case signedScore:
+			ctx.Signed = words[i].Score / maxAbs
+		default:
+			ctx.Signed = 2*ctx.Ratio - 1
+		}
+		words[i].Color = ensureContrast(scheme.Color(words[i], ctx), bg, g.config.minContrast())
+	}
+	return nil
+}
+
+// seriesColors は折れ線グラフの系列やトピックなど、区別して描画する対象に色スキームの色を割り当てる
+// 各対象を出現回数の順位の単語とみなして色を決め、背景色とのコントラストを確認して調整する
+func (g *Generator) seriesColors(series []WordCount) ([]string, error) {
+	words := slices.Clone(series)
+	if err := g.applyColors(words, func(wc WordCount) float64 { return float64(wc.Count) }); err != nil {
+		return nil, err
+	}
+	colors := make([]string, len(words))
+	for i, w := range words {
+		colors[i] = w.Color
+	}
+	return colors, nil
+}
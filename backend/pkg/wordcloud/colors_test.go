@@ -0,0 +1,68 @@
+package wordcloud
+
+import (
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRenderersUseBackground(t *testing.T) {
+	const background = "#112233"
+	processor := newTestFileProcessor(t, func(config *Config) {
+		config.Background = background
+		config.ColorScheme = ColorCategorical
+	})
+
+	network := &Network{
+		Nodes: []NetworkNode{{ID: "deploy", Count: 3, X: 0.3, Y: 0.5}, {ID: "review", Count: 1, X: 0.7, Y: 0.5}},
+		Edges: []NetworkEdge{{Source: "deploy", Target: "review", Weight: 1}},
+	}
+	dir := t.TempDir()
+	tests := []struct {
+		name   string
+		path   string
+		export func(path string) error
+	}{
+		{"推移グラフ", filepath.Join(dir, "trends.png"), func(path string) error {
+			return processor.ExportTrendsPNG(animationTrends(), path, 0)
+		}},
+		{"共起ネットワーク", filepath.Join(dir, "network.png"), func(path string) error {
+			return processor.ExportNetwork(network, path)
+		}},
+		{"アニメーション", filepath.Join(dir, "trends.gif"), func(path string) error {
+			return processor.ExportAnimation(animationTrends(), path, animationOptions(AnimationGIF))
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.export(tt.path); err != nil {
+				t.Fatalf("出力に失敗: %v", err)
+			}
+			file, err := os.Open(tt.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer file.Close()
+
+			var img image.Image
+			if filepath.Ext(tt.path) == ".gif" {
+				img, err = gif.Decode(file)
+			} else {
+				img, err = png.Decode(file)
+			}
+			if err != nil {
+				t.Fatalf("画像を読み込めない: %v", err)
+			}
+			// 右下の隅は単語や凡例を描画しない
+			bounds := img.Bounds()
+			r, g, b, _ := img.At(bounds.Max.X-1, bounds.Max.Y-1).RGBA()
+			if got := hexColor(color.RGBA{R: uint8(r >> 8), G: uint8(g >> 8), B: uint8(b >> 8), A: 0xff}); got != background {
+				t.Errorf("背景色 = %s, want %s", got, background)
+			}
+		})
+	}
+}
@@ -0,0 +1,210 @@
+package wordcloud
+
+import (
+	"cmp"
+	"fmt"
+	"log/slog"
+	"math"
+	"path/filepath"
+	"sort"
+
+	"github.com/fogleman/gg"
+)
+
+// 比較ワードクラウドの表示方式
+const (
+	ComparisonSplit       = "split"       // 2つのコーパスそれぞれに特徴的な単語を左右に並べる
+	ComparisonCommonality = "commonality" // 両方のコーパスに共通して現れる単語を表示する
+)
+
+// 比較ワードクラウドで各コーパスに使う色
+const (
+	comparisonColorA = "#D62728"
+	comparisonColorB = "#1F77B4"
+)
+
+// ComparisonWord は2つのコーパスにおける単語の出現状況
+type ComparisonWord struct {
+	Text   string  `json:"text"`   // 単語
+	CountA int     `json:"countA"` // コーパスAでの出現回数
+	CountB int     `json:"countB"` // コーパスBでの出現回数
+	FreqA  float64 `json:"freqA"`  // コーパスAでの相対頻度
+	FreqB  float64 `json:"freqB"`  // コーパスBでの相対頻度
+	Score  float64 `json:"score"`  // 対数尤度比（正ならA、負ならBに特徴的）
+}
+
+// Comparison は2つのコーパスの比較結果
+type Comparison struct {
+	LabelA string           `json:"labelA"` // コーパスAの名前
+	LabelB string           `json:"labelB"` // コーパスBの名前
+	TotalA int              `json:"totalA"` // コーパスAの単語の総出現回数
+	TotalB int              `json:"totalB"` // コーパスBの単語の総出現回数
+	Words  []ComparisonWord `json:"words"`  // 有意性の絶対値が大きい順の単語
+}
+
+// Compare は2つのメッセージ群を比較し、単語ごとの相対頻度と有意性を計算
+func (g *Generator) Compare(labelA string, a []Message, labelB string, b []Message) (*Comparison, error) {
+	statsA := g.countWords(a, nil)
+	statsB := g.countWords(b, nil)
+	if statsA.total == 0 || statsB.total == 0 {
+		return nil, fmt.Errorf("比較対象のどちらかに単語が含まれていません")
+	}
+
+	words := make(map[string]bool)
+	for word := range statsA.counts {
+		words[word] = true
+	}
+	for word := range statsB.counts {
+		words[word] = true
+	}
+
+	c := &Comparison{
+		LabelA: labelA,
+		LabelB: labelB,
+		TotalA: statsA.total,
+		TotalB: statsB.total,
+	}
+	for word := range words {
+		countA, countB := statsA.counts[word], statsB.counts[word]
+		if countA+countB < g.config.MinCount {
+			continue
+		}
+		c.Words = append(c.Words, ComparisonWord{
+			Text:   word,
+			CountA: countA,
+			CountB: countB,
+			FreqA:  float64(countA) / float64(statsA.total),
+			FreqB:  float64(countB) / float64(statsB.total),
+			Score:  logLikelihood(countA, statsA.total, countB, statsB.total),
+		})
+	}
+
+	sort.Slice(c.Words, func(i, j int) bool {
+		si, sj := math.Abs(c.Words[i].Score), math.Abs(c.Words[j].Score)
+		if si != sj {
+			return si > sj
+		}
+		return c.Words[i].Text < c.Words[j].Text
+	})
+
+	g.logger.Info("比較が完了しました", slog.Group("a", "label", labelA, "tokens", statsA.total), slog.Group("b", "label", labelB, "tokens", statsB.total), "words", len(c.Words))
+	return c, nil
+}
+
+// SplitClouds は各コーパスに特徴的な単語をそれぞれ最大MaxWords/2語ずつ返す
+// フォントサイズは有意性の絶対値に比例する
+func (g *Generator) SplitClouds(c *Comparison) (a, b []WordCount) {
+	limit := max(g.config.MaxWords/2, 1)
+	for _, w := range c.Words {
+		switch {
+		case w.Score > 0 && len(a) < limit:
+			a = append(a, WordCount{Text: w.Text, Count: w.CountA, Score: w.Score, Color: comparisonColorA})
+		case w.Score < 0 && len(b) < limit:
+			b = append(b, WordCount{Text: w.Text, Count: w.CountB, Score: -w.Score, Color: comparisonColorB})
+		}
+	}
+
+	maxScore := 0.0
+	for _, words := range [][]WordCount{a, b} {
+		for _, w := range words {
+			maxScore = math.Max(maxScore, w.Score)
+		}
+	}
+	for _, words := range [][]WordCount{a, b} {
+		for i := range words {
+			words[i].FontSize = g.linearFontSize(words[i].Score / maxScore)
+		}
+	}
+	return a, b
+}
+
+// CommonalityCloud は両方のコーパスに共通して現れる単語を返す
+// スコアは2つの相対頻度の小さい方で、どちらでもよく使われる単語ほど大きくなる
+func (g *Generator) CommonalityCloud(c *Comparison) ([]WordCount, error) {
+	var words []WordCount
+	for _, w := range c.Words {
+		if w.CountA == 0 || w.CountB == 0 {
+			continue
+		}
+		words = append(words, WordCount{
+			Text:  w.Text,
+			Count: w.CountA + w.CountB,
+			Score: math.Min(w.FreqA, w.FreqB),
+		})
+	}
+
+	sort.Slice(words, func(i, j int) bool {
+		return words[i].Score > words[j].Score
+	})
+	if len(words) > g.config.MaxWords {
+		words = words[:g.config.MaxWords]
+	}
+
+	score := func(wc WordCount) float64 { return wc.Score }
+	if err := g.scaleFontSizes(words, cmp.Or(g.config.FontScale, FontScaleLinear), score); err != nil {
+		return nil, err
+	}
+	if err := g.applyColors(words, score); err != nil {
+		return nil, err
+	}
+	return words, nil
+}
+
+// linearFontSize は0から1の比率を最小・最大フォントサイズの間に線形に割り当てる
+func (g *Generator) linearFontSize(ratio float64) int {
+	ratio = math.Max(0, math.Min(1, ratio))
+	return g.config.MinFontSize + int(ratio*float64(g.config.MaxFontSize-g.config.MinFontSize))
+}
+
+// CompareCSV は2つのCSVファイルを読み込んで比較
+func (fp *FileProcessor) CompareCSV(pathA, pathB string, messageColumn int) (*Comparison, error) {
+	a, err := fp.ReadCSV(pathA, messageColumn)
+	if err != nil {
+		return nil, err
+	}
+	b, err := fp.ReadCSV(pathB, messageColumn)
+	if err != nil {
+		return nil, err
+	}
+	return fp.generator.Compare(filepath.Base(pathA), a, filepath.Base(pathB), b)
+}
+
+// ExportComparisonPNG は比較結果を左右に分割したワードクラウドとしてPNG画像に出力
+// 左側にコーパスA、右側にコーパスBに特徴的な単語を配置する
+func (fp *FileProcessor) ExportComparisonPNG(c *Comparison, outputPath string) error {
+	a, b := fp.generator.SplitClouds(c)
+	fp.generator.logger.Debug("比較画像の描画を開始します", slog.Group("a", "label", c.LabelA, "words", len(a)), slog.Group("b", "label", c.LabelB, "words", len(b)))
+
+	width, height := float64(fp.config.Width), float64(fp.config.Height)
+	dc := gg.NewContext(fp.config.Width, fp.config.Height)
+	dc.SetColor(parseHexColor(fp.config.background()))
+	dc.Clear()
+
+	font, err := fp.loadFont()
+	if err != nil {
+		return err
+	}
+
+	// 見出しと区切り線
+	const headerHeight = 32.0
+	dc.SetFontFace(newFace(font, 16))
+	dc.SetHexColor(comparisonColorA)
+	dc.DrawStringAnchored(c.LabelA, width/4, headerHeight/2, 0.5, 0.5)
+	dc.SetHexColor(comparisonColorB)
+	dc.DrawStringAnchored(c.LabelB, width*3/4, headerHeight/2, 0.5, 0.5)
+	dc.SetHexColor("#CCCCCC")
+	dc.SetLineWidth(1)
+	dc.DrawLine(width/2, headerHeight, width/2, height)
+	dc.Stroke()
+
+	colorOf := func(w WordCount) string { return w.Color }
+	fp.drawWords(dc, font, a, Rectangle{X: 0, Y: headerHeight, W: width / 2, H: height - headerHeight}, colorOf)
+	fp.drawWords(dc, font, b, Rectangle{X: width / 2, Y: headerHeight, W: width / 2, H: height - headerHeight}, colorOf)
+
+	if err := dc.SavePNG(outputPath); err != nil {
+		return fmt.Errorf("PNG画像の保存に失敗: %w", err)
+	}
+
+	fp.generator.logger.Info("比較画像を出力しました", "path", outputPath)
+	return nil
+}
@@ -0,0 +1,564 @@
+package wordcloud
+
+import (
+	"encoding/xml"
+	"fmt"
+	"html"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/fogleman/gg"
+)
+
+// 共起の強さの指標
+const (
+	MeasureJaccard = "jaccard" // Jaccard係数
+	MeasurePMI     = "pmi"     // 自己相互情報量
+)
+
+// CooccurrenceOptions は共起ネットワークの設定
+type CooccurrenceOptions struct {
+	Window       int    // 共起とみなす単語間の距離（0以下はメッセージ全体）
+	Measure      string // エッジの重み（jaccard/pmi）
+	MaxNodes     int    // ノードにする単語の最大数（出現メッセージ数の多い順）
+	MaxEdges     int    // エッジの最大数（重みの大きい順、0以下は無制限）
+	MinEdgeCount int    // エッジにする最小の共起メッセージ数
+}
+
+// DefaultCooccurrenceOptions はデフォルトの共起ネットワーク設定を返す
+func DefaultCooccurrenceOptions() CooccurrenceOptions {
+	return CooccurrenceOptions{
+		Window:       0,
+		Measure:      MeasureJaccard,
+		MaxNodes:     60,
+		MaxEdges:     150,
+		MinEdgeCount: 2,
+	}
+}
+
+// NetworkNode は共起ネットワークのノード（単語）
+type NetworkNode struct {
+	ID    string  `json:"id"`    // 単語
+	Count int     `json:"count"` // 単語が出現したメッセージ数
+	X     float64 `json:"x"`     // レイアウト後のX座標（0〜1）
+	Y     float64 `json:"y"`     // レイアウト後のY座標（0〜1）
+}
+
+// NetworkEdge は共起ネットワークのエッジ
+type NetworkEdge struct {
+	Source string  `json:"source"` // 単語1
+	Target string  `json:"target"` // 単語2
+	Count  int     `json:"count"`  // 共起したメッセージ数
+	Weight float64 `json:"weight"` // 共起の強さ
+}
+
+// Network は単語の共起ネットワーク
+type Network struct {
+	Measure string        `json:"measure"` // エッジの重みの指標
+	Nodes   []NetworkNode `json:"nodes"`
+	Edges   []NetworkEdge `json:"edges"`
+}
+
+// Cooccurrence はメッセージごとの単語の共起を集計してネットワークを作成
+func (g *Generator) Cooccurrence(messages []Message, opts CooccurrenceOptions) (*Network, error) {
+	if opts.Measure != MeasureJaccard && opts.Measure != MeasurePMI {
+		return nil, fmt.Errorf("不明な共起の指標です: %s", opts.Measure)
+	}
+
+	// メッセージごとに共起する単語の組を集める
+	df := make(map[string]int)
+	var units []map[[2]string]bool
+	var unitWords []map[string]bool
+	for _, msg := range ExcludeUsers(messages, g.config.ExcludeUsers...) {
+		var words []string
+		for _, tokens := range g.analyzeSentences(msg.Text) {
+			for _, token := range tokens {
+				words = append(words, token.BaseForm)
+			}
+		}
+		if len(words) == 0 {
+			continue
+		}
+
+		seen := make(map[string]bool)
+		for _, w := range words {
+			if !seen[w] {
+				seen[w] = true
+				df[w]++
+			}
+		}
+		pairs := make(map[[2]string]bool)
+		for i := range words {
+			for j := i + 1; j < len(words); j++ {
+				if opts.Window > 0 && j-i > opts.Window {
+					break
+				}
+				if words[i] != words[j] {
+					pairs[orderedPair(words[i], words[j])] = true
+				}
+			}
+		}
+		units = append(units, pairs)
+		unitWords = append(unitWords, seen)
+	}
+	if len(units) == 0 {
+		return nil, fmt.Errorf("共起の集計に失敗: %w", ErrNoWords)
+	}
+
+	// 出現メッセージ数の多い単語をノードにする
+	var nodes []NetworkNode
+	for word, count := range df {
+		if count >= g.config.MinCount {
+			nodes = append(nodes, NetworkNode{ID: word, Count: count})
+		}
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		if nodes[i].Count != nodes[j].Count {
+			return nodes[i].Count > nodes[j].Count
+		}
+		return nodes[i].ID < nodes[j].ID
+	})
+	if opts.MaxNodes > 0 && len(nodes) > opts.MaxNodes {
+		nodes = nodes[:opts.MaxNodes]
+	}
+	isNode := make(map[string]bool, len(nodes))
+	for _, n := range nodes {
+		isNode[n.ID] = true
+	}
+
+	cooc := make(map[[2]string]int)
+	for _, pairs := range units {
+		for pair := range pairs {
+			if isNode[pair[0]] && isNode[pair[1]] {
+				cooc[pair]++
+			}
+		}
+	}
+
+	n := float64(len(units))
+	var edges []NetworkEdge
+	for pair, count := range cooc {
+		if count < max(opts.MinEdgeCount, 1) {
+			continue
+		}
+		a, b := float64(df[pair[0]]), float64(df[pair[1]])
+		weight := float64(count) / (a + b - float64(count))
+		if opts.Measure == MeasurePMI {
+			weight = math.Log(float64(count) * n / (a * b))
+		}
+		edges = append(edges, NetworkEdge{Source: pair[0], Target: pair[1], Count: count, Weight: weight})
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].Weight != edges[j].Weight {
+			return edges[i].Weight > edges[j].Weight
+		}
+		return edges[i].Source+edges[i].Target < edges[j].Source+edges[j].Target
+	})
+	if opts.MaxEdges > 0 && len(edges) > opts.MaxEdges {
+		edges = edges[:opts.MaxEdges]
+	}
+
+	network := &Network{Measure: opts.Measure, Nodes: nodes, Edges: edges}
+	network.layout()
+
+	g.logger.Info("共起ネットワークの作成が完了しました", "nodes", len(nodes), "edges", len(edges))
+	return network, nil
+}
+
+// orderedPair は2つの単語を辞書順に並べた組を返す
+func orderedPair(a, b string) [2]string {
+	if a > b {
+		a, b = b, a
+	}
+	return [2]string{a, b}
+}
+
+// layoutIterations は力学モデルによる配置の反復回数
+const layoutIterations = 300
+
+// layout はFruchterman-Reingold法でノードを0〜1の範囲に配置する
+// 初期配置は円周上の等間隔とし、乱数を使わないため結果は毎回同じになる
+func (nw *Network) layout() {
+	count := len(nw.Nodes)
+	if count == 0 {
+		return
+	}
+
+	index := make(map[string]int, count)
+	pos := make([][2]float64, count)
+	for i, node := range nw.Nodes {
+		index[node.ID] = i
+		angle := 2 * math.Pi * float64(i) / float64(count)
+		pos[i] = [2]float64{0.5 + 0.4*math.Cos(angle), 0.5 + 0.4*math.Sin(angle)}
+	}
+
+	k := math.Sqrt(1 / float64(count))
+	temperature := 0.1
+	for iter := 0; iter < layoutIterations; iter++ {
+		disp := make([][2]float64, count)
+
+		// 全ノード間の斥力
+		for i := 0; i < count; i++ {
+			for j := i + 1; j < count; j++ {
+				dx, dy := pos[i][0]-pos[j][0], pos[i][1]-pos[j][1]
+				dist := math.Max(math.Hypot(dx, dy), 1e-4)
+				force := k * k / dist
+				disp[i][0] += dx / dist * force
+				disp[i][1] += dy / dist * force
+				disp[j][0] -= dx / dist * force
+				disp[j][1] -= dy / dist * force
+			}
+		}
+
+		// エッジで結ばれたノード間の引力
+		for _, e := range nw.Edges {
+			i, j := index[e.Source], index[e.Target]
+			dx, dy := pos[i][0]-pos[j][0], pos[i][1]-pos[j][1]
+			dist := math.Max(math.Hypot(dx, dy), 1e-4)
+			force := dist * dist / k
+			disp[i][0] -= dx / dist * force
+			disp[i][1] -= dy / dist * force
+			disp[j][0] += dx / dist * force
+			disp[j][1] += dy / dist * force
+		}
+
+		for i := range pos {
+			d := math.Max(math.Hypot(disp[i][0], disp[i][1]), 1e-9)
+			step := math.Min(d, temperature)
+			pos[i][0] = math.Min(1, math.Max(0, pos[i][0]+disp[i][0]/d*step))
+			pos[i][1] = math.Min(1, math.Max(0, pos[i][1]+disp[i][1]/d*step))
+		}
+		temperature *= 0.98
+	}
+
+	// 描画領域全体を使うように0〜1へ拡大する
+	minX, minY, maxX, maxY := 1.0, 1.0, 0.0, 0.0
+	for _, p := range pos {
+		minX, maxX = math.Min(minX, p[0]), math.Max(maxX, p[0])
+		minY, maxY = math.Min(minY, p[1]), math.Max(maxY, p[1])
+	}
+	scale := func(v, lo, hi float64) float64 {
+		if hi-lo < 1e-9 {
+			return 0.5
+		}
+		return (v - lo) / (hi - lo)
+	}
+	for i := range nw.Nodes {
+		nw.Nodes[i].X, nw.Nodes[i].Y = scale(pos[i][0], minX, maxX), scale(pos[i][1], minY, maxY)
+	}
+}
+
+// CooccurrenceForCSV はCSVファイルを読み込んで共起ネットワークを作成
+func (fp *FileProcessor) CooccurrenceForCSV(inputPath string, messageColumn int, opts CooccurrenceOptions) (*Network, error) {
+	messages, err := fp.ReadCSV(inputPath, messageColumn)
+	if err != nil {
+		return nil, err
+	}
+	return fp.generator.Cooccurrence(messages, opts)
+}
+
+// ExportNetwork は共起ネットワークを拡張子に応じた形式で出力
+// .json・.graphml・.gexf・.png・.svg に対応する
+func (fp *FileProcessor) ExportNetwork(nw *Network, outputPath string) error {
+	switch strings.ToLower(filepath.Ext(outputPath)) {
+	case ".json":
+		return writeJSON(nw, outputPath)
+	case ".graphml":
+		return writeXML(nw.graphML(), outputPath)
+	case ".gexf":
+		return writeXML(nw.gexf(), outputPath)
+	case ".png":
+		return fp.exportNetworkPNG(nw, outputPath)
+	case ".svg":
+		return fp.exportNetworkSVG(nw, outputPath)
+	default:
+		return fmt.Errorf("対応していない出力形式です: %s", outputPath)
+	}
+}
+
+// writeXML は値をXML宣言付きのXMLファイルとして出力
+func writeXML(data any, outputPath string) error {
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		return fmt.Errorf("出力ディレクトリの作成に失敗: %w", err)
+	}
+
+	file, err := os.Create(outputPath)
+	if err != nil {
+		return fmt.Errorf("出力ファイルの作成に失敗: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := file.WriteString(xml.Header); err != nil {
+		return fmt.Errorf("XMLの書き込みに失敗: %w", err)
+	}
+	encoder := xml.NewEncoder(file)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(data); err != nil {
+		return fmt.Errorf("XMLの書き込みに失敗: %w", err)
+	}
+	return nil
+}
+
+// graphML 形式の要素
+type (
+	graphMLDoc struct {
+		XMLName xml.Name     `xml:"graphml"`
+		XMLNS   string       `xml:"xmlns,attr"`
+		Keys    []graphMLKey `xml:"key"`
+		Graph   graphMLGraph `xml:"graph"`
+	}
+	graphMLKey struct {
+		ID       string `xml:"id,attr"`
+		For      string `xml:"for,attr"`
+		AttrName string `xml:"attr.name,attr"`
+		AttrType string `xml:"attr.type,attr"`
+	}
+	graphMLGraph struct {
+		EdgeDefault string        `xml:"edgedefault,attr"`
+		Nodes       []graphMLNode `xml:"node"`
+		Edges       []graphMLEdge `xml:"edge"`
+	}
+	graphMLNode struct {
+		ID   string        `xml:"id,attr"`
+		Data []graphMLData `xml:"data"`
+	}
+	graphMLEdge struct {
+		Source string        `xml:"source,attr"`
+		Target string        `xml:"target,attr"`
+		Data   []graphMLData `xml:"data"`
+	}
+	graphMLData struct {
+		Key   string `xml:"key,attr"`
+		Value string `xml:",chardata"`
+	}
+)
+
+// graphML はGraphML形式の文書を作成
+func (nw *Network) graphML() graphMLDoc {
+	doc := graphMLDoc{
+		XMLNS: "http://graphml.graphdrawing.org/xmlns",
+		Keys: []graphMLKey{
+			{ID: "count", For: "node", AttrName: "count", AttrType: "int"},
+			{ID: "x", For: "node", AttrName: "x", AttrType: "double"},
+			{ID: "y", For: "node", AttrName: "y", AttrType: "double"},
+			{ID: "cooccurrence", For: "edge", AttrName: "count", AttrType: "int"},
+			{ID: "weight", For: "edge", AttrName: "weight", AttrType: "double"},
+		},
+		Graph: graphMLGraph{EdgeDefault: "undirected"},
+	}
+	for _, n := range nw.Nodes {
+		doc.Graph.Nodes = append(doc.Graph.Nodes, graphMLNode{ID: n.ID, Data: []graphMLData{
+			{Key: "count", Value: fmt.Sprint(n.Count)},
+			{Key: "x", Value: fmt.Sprint(n.X)},
+			{Key: "y", Value: fmt.Sprint(n.Y)},
+		}})
+	}
+	for _, e := range nw.Edges {
+		doc.Graph.Edges = append(doc.Graph.Edges, graphMLEdge{Source: e.Source, Target: e.Target, Data: []graphMLData{
+			{Key: "cooccurrence", Value: fmt.Sprint(e.Count)},
+			{Key: "weight", Value: fmt.Sprint(e.Weight)},
+		}})
+	}
+	return doc
+}
+
+// GEXF 形式の要素
+type (
+	gexfDoc struct {
+		XMLName xml.Name  `xml:"gexf"`
+		XMLNS   string    `xml:"xmlns,attr"`
+		VizNS   string    `xml:"xmlns:viz,attr"`
+		Version string    `xml:"version,attr"`
+		Graph   gexfGraph `xml:"graph"`
+	}
+	gexfGraph struct {
+		DefaultEdgeType string     `xml:"defaultedgetype,attr"`
+		Nodes           []gexfNode `xml:"nodes>node"`
+		Edges           []gexfEdge `xml:"edges>edge"`
+	}
+	gexfNode struct {
+		ID       string       `xml:"id,attr"`
+		Label    string       `xml:"label,attr"`
+		Size     gexfSize     `xml:"viz:size"`
+		Position gexfPosition `xml:"viz:position"`
+	}
+	gexfSize struct {
+		Value float64 `xml:"value,attr"`
+	}
+	gexfPosition struct {
+		X float64 `xml:"x,attr"`
+		Y float64 `xml:"y,attr"`
+	}
+	gexfEdge struct {
+		ID     int     `xml:"id,attr"`
+		Source string  `xml:"source,attr"`
+		Target string  `xml:"target,attr"`
+		Weight float64 `xml:"weight,attr"`
+	}
+)
+
+// gexf はGEXF形式の文書を作成
+func (nw *Network) gexf() gexfDoc {
+	doc := gexfDoc{
+		XMLNS:   "http://gexf.net/1.3",
+		VizNS:   "http://gexf.net/1.3/viz",
+		Version: "1.3",
+		Graph:   gexfGraph{DefaultEdgeType: "undirected"},
+	}
+	for _, n := range nw.Nodes {
+		doc.Graph.Nodes = append(doc.Graph.Nodes, gexfNode{
+			ID:       n.ID,
+			Label:    n.ID,
+			Size:     gexfSize{Value: float64(n.Count)},
+			Position: gexfPosition{X: n.X * 1000, Y: n.Y * 1000},
+		})
+	}
+	for i, e := range nw.Edges {
+		doc.Graph.Edges = append(doc.Graph.Edges, gexfEdge{ID: i, Source: e.Source, Target: e.Target, Weight: e.Weight})
+	}
+	return doc
+}
+
+// networkGeometry は描画用のノード座標と大きさ、エッジの太さを計算
+type networkGeometry struct {
+	x, y, r map[string]float64
+	width   []float64
+}
+
+// geometry は画像の大きさに合わせてネットワークの描画位置を計算
+func (nw *Network) geometry(width, height float64) networkGeometry {
+	const margin = 40.0
+	g := networkGeometry{x: map[string]float64{}, y: map[string]float64{}, r: map[string]float64{}}
+
+	maxCount := 1
+	for _, n := range nw.Nodes {
+		maxCount = max(maxCount, n.Count)
+	}
+	for _, n := range nw.Nodes {
+		g.x[n.ID] = margin + n.X*(width-2*margin)
+		g.y[n.ID] = margin + n.Y*(height-2*margin)
+		g.r[n.ID] = 4 + 16*math.Sqrt(float64(n.Count)/float64(maxCount))
+	}
+
+	minWeight, maxWeight := math.Inf(1), math.Inf(-1)
+	for _, e := range nw.Edges {
+		minWeight, maxWeight = math.Min(minWeight, e.Weight), math.Max(maxWeight, e.Weight)
+	}
+	for _, e := range nw.Edges {
+		ratio := 1.0
+		if maxWeight > minWeight {
+			ratio = (e.Weight - minWeight) / (maxWeight - minWeight)
+		}
+		g.width = append(g.width, 0.5+3.5*ratio)
+	}
+	return g
+}
+
+// networkEdgeColor は共起ネットワークのエッジの色
+const networkEdgeColor = "#B0B0B0"
+
+// nodeColors はノードの色を出現したメッセージ数に応じて色スキームで割り当てる
+func (fp *FileProcessor) nodeColors(nw *Network) (map[string]string, error) {
+	nodes := make([]WordCount, len(nw.Nodes))
+	for i, n := range nw.Nodes {
+		nodes[i] = WordCount{Text: n.ID, Count: n.Count}
+	}
+	sort.SliceStable(nodes, func(i, j int) bool { return nodes[i].Count > nodes[j].Count })
+	colors, err := fp.generator.seriesColors(nodes)
+	if err != nil {
+		return nil, err
+	}
+	colorOf := make(map[string]string, len(nodes))
+	for i, n := range nodes {
+		colorOf[n.Text] = colors[i]
+	}
+	return colorOf, nil
+}
+
+// exportNetworkPNG は共起ネットワークをPNG画像として出力
+func (fp *FileProcessor) exportNetworkPNG(nw *Network, outputPath string) error {
+	font, err := fp.loadFont()
+	if err != nil {
+		return err
+	}
+
+	colorOf, err := fp.nodeColors(nw)
+	if err != nil {
+		return err
+	}
+
+	width, height := float64(fp.config.Width), float64(fp.config.Height)
+	geo := nw.geometry(width, height)
+
+	dc := gg.NewContext(fp.config.Width, fp.config.Height)
+	dc.SetColor(parseHexColor(fp.config.background()))
+	dc.Clear()
+
+	dc.SetHexColor(networkEdgeColor)
+	for i, e := range nw.Edges {
+		dc.SetLineWidth(geo.width[i])
+		dc.DrawLine(geo.x[e.Source], geo.y[e.Source], geo.x[e.Target], geo.y[e.Target])
+		dc.Stroke()
+	}
+
+	dc.SetFontFace(newFace(font, 12))
+	textColor := fp.config.textColor()
+	for _, n := range nw.Nodes {
+		dc.SetHexColor(colorOf[n.ID])
+		dc.DrawCircle(geo.x[n.ID], geo.y[n.ID], geo.r[n.ID])
+		dc.Fill()
+		dc.SetHexColor(textColor)
+		dc.DrawStringAnchored(n.ID, geo.x[n.ID], geo.y[n.ID]+geo.r[n.ID]+8, 0.5, 0.5)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		return fmt.Errorf("出力ディレクトリの作成に失敗: %w", err)
+	}
+	if err := dc.SavePNG(outputPath); err != nil {
+		return fmt.Errorf("PNG画像の保存に失敗: %w", err)
+	}
+
+	fp.generator.logger.Info("共起ネットワークを出力しました", "path", outputPath)
+	return nil
+}
+
+// exportNetworkSVG は共起ネットワークをSVG画像として出力
+func (fp *FileProcessor) exportNetworkSVG(nw *Network, outputPath string) error {
+	colorOf, err := fp.nodeColors(nw)
+	if err != nil {
+		return err
+	}
+	textColor := fp.config.textColor()
+
+	width, height := float64(fp.config.Width), float64(fp.config.Height)
+	geo := nw.geometry(width, height)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`+"\n",
+		fp.config.Width, fp.config.Height, fp.config.Width, fp.config.Height)
+	fmt.Fprintf(&b, `<rect width="100%%" height="100%%" fill="%s"/>`+"\n", hexColor(parseHexColor(fp.config.background())))
+	for i, e := range nw.Edges {
+		fmt.Fprintf(&b, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="%s" stroke-width="%.2f"/>`+"\n",
+			geo.x[e.Source], geo.y[e.Source], geo.x[e.Target], geo.y[e.Target], networkEdgeColor, geo.width[i])
+	}
+	for _, n := range nw.Nodes {
+		fmt.Fprintf(&b, `<circle cx="%.1f" cy="%.1f" r="%.1f" fill="%s"/>`+"\n",
+			geo.x[n.ID], geo.y[n.ID], geo.r[n.ID], colorOf[n.ID])
+		fmt.Fprintf(&b, `<text x="%.1f" y="%.1f" font-size="12" text-anchor="middle" dominant-baseline="middle" fill="%s">%s</text>`+"\n",
+			geo.x[n.ID], geo.y[n.ID]+geo.r[n.ID]+8, textColor, html.EscapeString(n.ID))
+	}
+	b.WriteString("</svg>\n")
+
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		return fmt.Errorf("出力ディレクトリの作成に失敗: %w", err)
+	}
+	if err := os.WriteFile(outputPath, []byte(b.String()), 0644); err != nil {
+		return fmt.Errorf("SVG画像の保存に失敗: %w", err)
+	}
+
+	fp.generator.logger.Info("共起ネットワークを出力しました", "path", outputPath)
+	return nil
+}
@@ -0,0 +1,41 @@
+package wordcloud
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrNoMessages は集計するメッセージがないエラー
+	ErrNoMessages = errors.New("メッセージがありません")
+
+	// ErrNoWords は最小出現回数に達した単語がなくワードクラウドを作成できないエラー
+	ErrNoWords = errors.New("ワードクラウドに表示できる単語がありません")
+
+	// ErrInvalidConfig は設定値が不正なエラー
+	ErrInvalidConfig = errors.New("設定が不正です")
+)
+
+// IsEmptyError はメッセージや単語がなくワードクラウドが空になるエラーかを判定
+func IsEmptyError(err error) bool {
+	return errors.Is(err, ErrNoMessages) || errors.Is(err, ErrNoWords)
+}
+
+// validate は単語の集計に使う設定を検証
+func (c Config) validate() error {
+	if c.MaxWords <= 0 {
+		return fmt.Errorf("%w: 最大単語数は1以上を指定してください: %d", ErrInvalidConfig, c.MaxWords)
+	}
+	if c.MinFontSize <= 0 || c.MaxFontSize < c.MinFontSize {
+		return fmt.Errorf("%w: フォントサイズの範囲が不正です: %d〜%d", ErrInvalidConfig, c.MinFontSize, c.MaxFontSize)
+	}
+	return nil
+}
+
+// validateCanvas は描画に使う設定を検証
+func (c Config) validateCanvas() error {
+	if c.Width <= 0 || c.Height <= 0 {
+		return fmt.Errorf("%w: 画像のサイズが不正です: %dx%d", ErrInvalidConfig, c.Width, c.Height)
+	}
+	return nil
+}
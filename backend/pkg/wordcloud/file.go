@@ -5,40 +5,92 @@ import (
 	"encoding/csv"
 	"encoding/json"
 	"fmt"
+	"html"
 	"io"
-	"log"
 	"math"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/fogleman/gg"
 	"github.com/golang/freetype/truetype"
+	xfont "golang.org/x/image/font"
 )
 
 // FileProcessor はファイル処理を行う構造体
 type FileProcessor struct {
 	generator *Generator
 	config    Config
+	filter    *Filter
 }
 
 // NewFileProcessor は新しいFileProcessorを作成
-func NewFileProcessor(config Config) (*FileProcessor, error) {
-	analyzer, err := NewAnalyzer()
-	if err != nil {
-		return nil, fmt.Errorf("アナライザーの初期化に失敗: %w", err)
+// analyzerがnilの場合は既定の設定のAnalyzerを作成し、optionsは内部で使用するGeneratorに適用される
+func NewFileProcessor(config Config, analyzer *Analyzer, options ...GeneratorOption) (*FileProcessor, error) {
+	var filter *Filter
+	var err error
+	if config.Filter != "" {
+		filter, err = ParseFilter(config.Filter, config.location())
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	generator := NewGenerator(config, analyzer)
+	if err := config.validateCanvas(); err != nil {
+		return nil, err
+	}
+	generator, err := NewGenerator(config, analyzer, options...)
+	if err != nil {
+		return nil, err
+	}
 
 	return &FileProcessor{
 		generator: generator,
 		config:    config,
+		filter:    filter,
 	}, nil
 }
 
+// Generator は内部で使用するGeneratorを返す
+func (fp *FileProcessor) Generator() *Generator {
+	return fp.generator
+}
+
 // ProcessCSV はCSVファイルを処理してワードクラウドデータを生成
-func (fp *FileProcessor) ProcessCSV(inputPath string, messageColumn int) ([]WordCount, error) {
-	log.Printf("CSVファイル '%s' の処理を開始します...", inputPath)
+// optionsで指定した進捗の通知先には読み込みと解析の両方の進捗を通知する
+func (fp *FileProcessor) ProcessCSV(inputPath string, messageColumn int, options ...GenerateOption) ([]WordCount, error) {
+	messages, err := fp.ReadCSV(inputPath, messageColumn, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	fp.generator.logger.Debug("ワードクラウドデータの生成を開始します")
+
+	// ワードクラウドデータの生成
+	return fp.generator.GenerateMessages(messages, options...)
+}
+
+// ProcessMessages はSlackから取得したメッセージなどからワードクラウドデータを生成
+// Config.Filterが指定されている場合は条件を満たすメッセージだけを集計する
+func (fp *FileProcessor) ProcessMessages(messages []Message, options ...GenerateOption) ([]WordCount, error) {
+	if fp.filter != nil {
+		filtered := FilterMessages(messages, fp.filter)
+		fp.generator.logger.Info("フィルターでメッセージを除外しました", "filter", fp.filter.String(), "count", len(messages)-len(filtered))
+		messages = filtered
+	}
+
+	fp.generator.logger.Debug("ワードクラウドデータの生成を開始します")
+	return fp.generator.GenerateMessages(messages, options...)
+}
+
+// ReadCSV はCSVファイルからメッセージを読み込む
+// メッセージ以外の列はヘッダー名（Timestamp, UserID, Username, ThreadTS）から判定する
+// Config.Filterが指定されている場合は条件を満たす行だけを返す
+func (fp *FileProcessor) ReadCSV(inputPath string, messageColumn int, options ...GenerateOption) ([]Message, error) {
+	opts := newGenerateOptions(options)
+	fp.generator.logger.Debug("CSVファイルの読み込みを開始します", "path", inputPath)
+	start := time.Now()
 
 	file, err := os.Open(inputPath)
 	if err != nil {
@@ -63,16 +115,19 @@ func (fp *FileProcessor) ProcessCSV(inputPath string, messageColumn int) ([]Word
 
 	reader := csv.NewReader(file)
 
-	// ヘッダーをスキップ
-	if _, err := reader.Read(); err != nil {
+	// ヘッダーから列の位置を取得
+	header, err := reader.Read()
+	if err != nil {
 		return nil, fmt.Errorf("ヘッダーの読み込みに失敗: %w", err)
 	}
+	columns := newCSVColumns(header, messageColumn)
 	lineCount-- // ヘッダー行を除く
 
-	// メッセージテキストを収集
-	var texts []string
+	// メッセージを収集
+	var messages []Message
 	processedLines := 0
-	lastProgress := 0
+	filteredLines := 0
+	reporter := newProgressReporter(opts.progress, StageReadCSV, lineCount)
 
 	for {
 		record, err := reader.Read()
@@ -84,28 +139,55 @@ func (fp *FileProcessor) ProcessCSV(inputPath string, messageColumn int) ([]Word
 		}
 
 		processedLines++
-		progress := (processedLines * 100) / lineCount
+		reporter.report(processedLines)
 
-		// 10%単位で進捗を表示
-		if progress/10 > lastProgress/10 {
-			log.Printf("CSVファイルの処理中... %d%%完了", progress)
-			lastProgress = progress
+		msg, ok := columns.message(record, fp.config.location())
+		if !ok {
+			continue
 		}
-
-		if len(record) > messageColumn {
-			texts = append(texts, record[messageColumn])
+		msg.Row, _ = reader.FieldPos(0)
+		if fp.filter != nil && !fp.filter.Match(msg) {
+			filteredLines++
+			continue
 		}
+		messages = append(messages, msg)
 	}
 
-	log.Printf("CSVファイルの読み込みが完了しました。%d行を処理しました。", processedLines)
-	log.Print("ワードクラウドデータの生成を開始します...")
-
-	// ワードクラウドデータの生成
-	return fp.generator.Generate(texts)
+	// 複数行のメッセージがあると行数の見積もりより少なくなるため、最後に実際の件数で終了を通知する
+	reporter.finish(processedLines)
+	fp.generator.logger.Info("CSVファイルの読み込みが完了しました", "path", inputPath, "rows", processedLines, "duration", time.Since(start))
+	if fp.filter != nil {
+		fp.generator.logger.Info("フィルターで行を除外しました", "filter", fp.filter.String(), "count", filteredLines)
+	}
+	return messages, nil
 }
 
 // ExportJSON はワードクラウドデータをJSONファイルに出力
 func (fp *FileProcessor) ExportJSON(data []WordCount, outputPath string) error {
+	return writeJSON(data, outputPath)
+}
+
+// ReadJSON はExportJSONで出力したワードクラウドデータを読み込む
+func (fp *FileProcessor) ReadJSON(inputPath string) ([]WordCount, error) {
+	data, err := os.ReadFile(inputPath)
+	if err != nil {
+		return nil, fmt.Errorf("JSONファイルの読み込みに失敗: %w", err)
+	}
+
+	var words []WordCount
+	if err := json.Unmarshal(data, &words); err != nil {
+		return nil, fmt.Errorf("JSONのパースに失敗: %w", err)
+	}
+	return words, nil
+}
+
+// ExportComparisonJSON は比較結果をJSONファイルに出力
+func (fp *FileProcessor) ExportComparisonJSON(c *Comparison, outputPath string) error {
+	return writeJSON(c, outputPath)
+}
+
+// writeJSON は値をインデント付きのJSONファイルとして出力
+func writeJSON(data any, outputPath string) error {
 	// 出力ディレクトリの作成
 	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
 		return fmt.Errorf("出力ディレクトリの作成に失敗: %w", err)
@@ -141,138 +223,273 @@ func (r Rectangle) Overlaps(other Rectangle) bool {
 }
 
 // ExportPNG はワードクラウドデータをPNG画像として出力
+// 単語の色はWordCount.Colorを使い、色がない単語には設定の色スキームで色を付ける
 func (fp *FileProcessor) ExportPNG(data []WordCount, outputPath string) error {
 	// デバッグ用のログ追加
-	log.Printf("処理開始: 単語数=%d", len(data))
+	fp.generator.logger.Debug("PNGの描画を開始します", "words", len(data))
+
+	data, err := fp.colored(data)
+	if err != nil {
+		return err
+	}
 
 	dc := gg.NewContext(fp.config.Width, fp.config.Height)
-	dc.SetRGB(1, 1, 1)
+	dc.SetColor(parseHexColor(fp.config.background()))
 	dc.Clear()
 
-	// フォント設定
-	fontPath := "/Library/Fonts/Arial Unicode.ttf"
-	fontBytes, err := os.ReadFile(fontPath)
+	font, err := fp.loadFont()
 	if err != nil {
-		return fmt.Errorf("フォントファイルの読み込みに失敗: %w", err)
+		return err
 	}
 
-	font, err := truetype.Parse(fontBytes)
+	region := Rectangle{W: float64(fp.config.Width), H: float64(fp.config.Height)}
+	drawPlaced(dc, font, fp.layout(dc, font, data, region), func(word WordCount) string { return word.Color })
+
+	// PNG画像として保存
+	if err := dc.SavePNG(outputPath); err != nil {
+		return fmt.Errorf("PNG画像の保存に失敗: %w", err)
+	}
+
+	fp.generator.logger.Info("PNGを出力しました", "path", outputPath)
+	return nil
+}
+
+// ExportSVG はワードクラウドデータをSVG画像として出力
+// 配置はPNGと同じフォントで計算し、文字はfont-familyに指定したフォントで描画される
+func (fp *FileProcessor) ExportSVG(data []WordCount, outputPath string) error {
+	data, err := fp.colored(data)
 	if err != nil {
-		return fmt.Errorf("フォントのパースに失敗: %w", err)
+		return err
 	}
 
-	// 頻出度の最大値と最小値を取得（'*'を除外）
-	maxCount := 0
-	minCount := math.MaxInt32
+	font, err := fp.loadFont()
+	if err != nil {
+		return err
+	}
+
+	dc := gg.NewContext(fp.config.Width, fp.config.Height)
+	region := Rectangle{W: float64(fp.config.Width), H: float64(fp.config.Height)}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`+"\n",
+		fp.config.Width, fp.config.Height, fp.config.Width, fp.config.Height)
+	fmt.Fprintf(&b, `<rect width="100%%" height="100%%" fill="%s"/>`+"\n", hexColor(parseHexColor(fp.config.background())))
+	for _, p := range fp.layout(dc, font, data, region) {
+		fmt.Fprintf(&b, `<text x="%.1f" y="%.1f" font-size="%d" font-family="sans-serif" fill="%s">%s</text>`+"\n",
+			p.X, p.Y, p.FontSize, hexColor(parseHexColor(p.Color)), html.EscapeString(p.Text))
+	}
+	b.WriteString("</svg>\n")
+
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		return fmt.Errorf("出力ディレクトリの作成に失敗: %w", err)
+	}
+	if err := os.WriteFile(outputPath, []byte(b.String()), 0644); err != nil {
+		return fmt.Errorf("SVG画像の保存に失敗: %w", err)
+	}
+
+	fp.generator.logger.Info("SVGを出力しました", "path", outputPath)
+	return nil
+}
+
+// colored は色を解析できない単語がある場合に、出現回数を値として色スキームの色を付けたコピーを返す
+func (fp *FileProcessor) colored(data []WordCount) ([]WordCount, error) {
 	for _, word := range data {
-		if word.Text == "*" {
-			continue // '*'は除外
-		}
-		if word.Count > maxCount {
-			maxCount = word.Count
-		}
-		if word.Count < minCount {
-			minCount = word.Count
+		if _, ok := parseColor(word.Color); !ok {
+			colored := append([]WordCount(nil), data...)
+			if err := fp.generator.applyColors(colored, func(wc WordCount) float64 { return float64(wc.Count) }); err != nil {
+				return nil, err
+			}
+			return colored, nil
 		}
 	}
+	return data, nil
+}
 
-	log.Printf("頻出度範囲: 最小=%d, 最大=%d", minCount, maxCount)
+// layout は単語を配置する（Config.FitCanvasが有効な場合は描画領域を埋めるようにフォントサイズを調整する）
+// 単語がない場合は代わりに中央へメッセージを配置する
+func (fp *FileProcessor) layout(dc *gg.Context, font *truetype.Font, data []WordCount, region Rectangle) []placedWord {
+	if len(data) == 0 {
+		return []placedWord{fp.placeholder(dc, font, region)}
+	}
+	var placed []placedWord
+	if fp.config.FitCanvas {
+		placed = fitWords(dc, font, data, region)
+	} else {
+		placed = placeWords(dc, font, data, region)
+	}
+	fp.warnUnplaced(data, placed)
+	return placed
+}
 
-	// 色を決定する関数
-	getColorHex := func(count int, text string) string {
-		if text == "*" {
-			return "#66CCFF" // '*'は最も薄い青に固定
+// warnUnplaced は配置できなかった単語を警告としてログに出力する
+func (fp *FileProcessor) warnUnplaced(data []WordCount, placed []placedWord) {
+	if len(placed) == len(data) {
+		return
+	}
+	found := make(map[string]bool, len(placed))
+	for _, p := range placed {
+		found[p.Text] = true
+	}
+	for _, word := range data {
+		if !found[word.Text] {
+			fp.generator.logger.Warn("最小サイズに縮小しても配置できない単語を省きました", "word", word.Text, "count", word.Count)
 		}
+	}
+}
 
-		// 頻出度に基づいて0-1の値を計算
-		ratio := float64(count-minCount) / float64(maxCount-minCount)
-
-		// 頻出度に応じて5段階の色を返す
-		switch {
-		case ratio >= 0.8:
-			return "#FF0000" // 赤（最も頻出）
-		case ratio >= 0.6:
-			return "#FF6600" // オレンジ
-		case ratio >= 0.4:
-			return "#0066FF" // 青
-		case ratio >= 0.2:
-			return "#3399FF" // 明るい青
-		default:
-			return "#66CCFF" // 最も薄い青
-		}
+// 単語がない場合に表示するメッセージ
+const (
+	PlaceholderText  = "表示できる単語がありません"
+	placeholderColor = "#999999"
+)
+
+// placeholder は単語がない場合にregionの中央へ表示するメッセージを配置する
+func (fp *FileProcessor) placeholder(dc *gg.Context, font *truetype.Font, region Rectangle) placedWord {
+	size := max(fp.config.MaxFontSize/2, 1)
+	dc.SetFontFace(newFace(font, float64(size)))
+	w, h := dc.MeasureString(PlaceholderText)
+	if w > region.W*0.9 {
+		// 描画領域の幅に収まるように縮小する
+		size = max(int(float64(size)*region.W*0.9/w), 1)
+		dc.SetFontFace(newFace(font, float64(size)))
+		w, h = dc.MeasureString(PlaceholderText)
+	}
+
+	word := WordCount{
+		Text:     PlaceholderText,
+		FontSize: size,
+		Color:    ensureContrast(placeholderColor, fp.config.background(), fp.config.minContrast()),
+	}
+	return placedWord{
+		WordCount: word,
+		X:         region.X + (region.W-w)/2,
+		Y:         region.Y + (region.H+h)/2,
+		W:         w,
+		H:         h,
+	}
+}
+
+// defaultFontPath はConfig.FontPathが未指定の場合に使用するフォント
+const defaultFontPath = "/Library/Fonts/Arial Unicode.ttf"
+
+// loadFont はフォントファイルを読み込む
+func (fp *FileProcessor) loadFont() (*truetype.Font, error) {
+	fontPath := fp.config.FontPath
+	if fontPath == "" {
+		fontPath = defaultFontPath
+	}
+	fontBytes, err := os.ReadFile(fontPath)
+	if err != nil {
+		return nil, fmt.Errorf("フォントファイルの読み込みに失敗: %w", err)
+	}
+
+	font, err := truetype.Parse(fontBytes)
+	if err != nil {
+		return nil, fmt.Errorf("フォントのパースに失敗: %w", err)
+	}
+	return font, nil
+}
+
+// newFace は指定したサイズのフォントフェイスを作成
+func newFace(font *truetype.Font, size float64) xfont.Face {
+	return truetype.NewFace(font, &truetype.Options{Size: size})
+}
+
+// placedWord は配置が決まった単語（X, Yは描画時の左下の基準点）
+type placedWord struct {
+	WordCount
+	X, Y, W, H float64
+}
+
+// drawWords は単語をregionの中心からスパイラル状に配置して描画
+func (fp *FileProcessor) drawWords(dc *gg.Context, font *truetype.Font, data []WordCount, region Rectangle, colorOf func(WordCount) string) {
+	placed := placeWords(dc, font, data, region)
+	fp.warnUnplaced(data, placed)
+	drawPlaced(dc, font, placed, colorOf)
+}
+
+// drawPlaced は配置が決まった単語を描画
+func drawPlaced(dc *gg.Context, font *truetype.Font, placed []placedWord, colorOf func(WordCount) string) {
+	for _, p := range placed {
+		dc.SetFontFace(newFace(font, float64(p.FontSize)))
+		dc.SetColor(parseHexColor(colorOf(p.WordCount)))
+		dc.DrawString(p.Text, p.X, p.Y)
 	}
+}
 
+// placeWords は単語をregionの中心からスパイラル状に配置し、配置できた単語の位置を返す
+// 配置できない単語はフォントサイズを縮小しながら再試行し、下限のサイズでも配置できない場合に省く（結果に含めない）
+// 下限より小さいフォントサイズ（JSONで省略された0など）の単語は下限のサイズで配置する
+func placeWords(dc *gg.Context, font *truetype.Font, data []WordCount, region Rectangle) []placedWord {
 	// 配置済みの単語の領域を管理するスライスを初期化
 	occupied := make([]Rectangle, 0)
+	placedWords := make([]placedWord, 0, len(data))
 
-	// 配置パラメータの調整
-	centerX := float64(fp.config.Width) / 2
-	centerY := float64(fp.config.Height) / 2
-	maxRadius := math.Min(float64(fp.config.Width), float64(fp.config.Height)) * 0.4
-	spiralDelta := 0.1 // スパイラルの増加率を小さくする
-
-	// 単語を描画
+	// 単語を配置
 	for _, word := range data {
-		fontSize := float64(word.FontSize)
-		face := truetype.NewFace(font, &truetype.Options{Size: fontSize})
-		dc.SetFontFace(face)
-		dc.SetHexColor(getColorHex(word.Count, word.Text))
-
-		// 単語の大きさを計算
-		w, h := dc.MeasureString(word.Text)
-
-		// スパイラル状に配置を試行
-		placed := false
-		for radius := float64(0); radius < maxRadius; radius += spiralDelta {
-			for angle := float64(0); angle < 2*math.Pi*radius; angle += spiralDelta {
-				x := centerX + math.Cos(angle)*radius - w/2
-				y := centerY + math.Sin(angle)*radius + h/2
-
-				// 画像の範囲内かチェック
-				if x < 0 || x+w > float64(fp.config.Width) ||
-					y-h < 0 || y > float64(fp.config.Height) {
-					continue
-				}
+		for size := max(word.FontSize, minPlaceFontSize); size >= minPlaceFontSize; size = min(size-1, int(float64(size)*shrinkFactor)) {
+			dc.SetFontFace(newFace(font, float64(size)))
+
+			// 単語の大きさを計算
+			w, h := dc.MeasureString(word.Text)
 
-				// 配置領域の作成
-				newRect := Rectangle{
+			if x, y, ok := findPosition(occupied, w, h, region); ok {
+				word.FontSize = size
+				placedWords = append(placedWords, placedWord{WordCount: word, X: x, Y: y, W: w, H: h})
+				occupied = append(occupied, Rectangle{
 					X: x - 2,     // マージンを追加
 					Y: y - h - 2, // マージンを追加
 					W: w + 4,     // マージンを追加
 					H: h + 4,     // マージンを追加
-				}
+				})
+				break
+			}
+		}
+	}
 
-				// 重なりチェック
-				overlap := false
-				for _, rect := range occupied {
-					if newRect.Overlaps(rect) {
-						overlap = true
-						break
-					}
-				}
+	return placedWords
+}
+
+// findPosition は幅w・高さhの単語を既存の単語と重ならずに置ける位置を
+// regionの中心から外側へスパイラル状に探し、描画時の左下の基準点を返す
+func findPosition(occupied []Rectangle, w, h float64, region Rectangle) (float64, float64, bool) {
+	// 配置パラメータの調整
+	centerX := region.X + region.W/2
+	centerY := region.Y + region.H/2
+	maxRadius := math.Hypot(region.W, region.H) / 2 // 四隅まで探索する
+	spiralDelta := 0.1                              // スパイラルの増加率を小さくする
+
+	for radius := float64(0); radius < maxRadius; radius += spiralDelta {
+		// 外側の円周ほど角度の刻みを細かくし、候補の間隔を約1ピクセルに保つ
+		step := spiralDelta
+		if radius > 1/spiralDelta {
+			step = 1 / radius
+		}
+		for angle := float64(0); angle < 2*math.Pi; angle += step {
+			x := centerX + math.Cos(angle)*radius - w/2
+			y := centerY + math.Sin(angle)*radius + h/2
+
+			// 描画領域の範囲内かチェック
+			if x < region.X || x+w > region.X+region.W ||
+				y-h < region.Y || y > region.Y+region.H {
+				continue
+			}
+
+			// 配置領域の作成
+			newRect := Rectangle{X: x - 2, Y: y - h - 2, W: w + 4, H: h + 4}
 
-				if !overlap {
-					dc.DrawString(word.Text, x, y)
-					occupied = append(occupied, newRect)
-					placed = true
+			// 重なりチェック
+			overlap := false
+			for _, rect := range occupied {
+				if newRect.Overlaps(rect) {
+					overlap = true
 					break
 				}
 			}
-			if placed {
-				break
+			if !overlap {
+				return x, y, true
 			}
 		}
-
-		if !placed {
-			log.Printf("警告: '%s' の配置に失敗しました", word.Text)
-		}
-	}
-
-	// PNG画像として保存
-	if err := dc.SavePNG(outputPath); err != nil {
-		return fmt.Errorf("PNG画像の保存に失敗: %w", err)
 	}
-
-	log.Printf("処理完了: %s", outputPath)
-	return nil
+	return 0, 0, false
 }
@@ -0,0 +1,34 @@
+package wordcloud
+
+import (
+	"testing"
+
+	"github.com/fogleman/gg"
+	"github.com/golang/freetype/truetype"
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+func TestPlaceWordsBelowMinimumSize(t *testing.T) {
+	font, err := truetype.Parse(goregular.TTF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	words := []WordCount{
+		{Text: "deploy", Count: 5, FontSize: 24},
+		{Text: "review", Count: 2, FontSize: minPlaceFontSize - 3}, // MinFontSizeが下限より小さい設定
+		{Text: "merge", Count: 1},                                  // JSONでfontSizeが省略された単語
+	}
+
+	dc := gg.NewContext(320, 240)
+	placed := placeWords(dc, font, words, Rectangle{W: 320, H: 240})
+
+	if len(placed) != len(words) {
+		t.Fatalf("配置できた単語が %d 個（期待値 %d 個）: %+v", len(placed), len(words), placed)
+	}
+	want := map[string]int{"deploy": 24, "review": minPlaceFontSize, "merge": minPlaceFontSize}
+	for _, p := range placed {
+		if p.FontSize != want[p.Text] {
+			t.Errorf("%s のフォントサイズ = %d, want %d", p.Text, p.FontSize, want[p.Text])
+		}
+	}
+}
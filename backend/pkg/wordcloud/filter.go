@@ -0,0 +1,393 @@
+package wordcloud
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Filter はメッセージを選択するためのフィルター式
+//
+// 式は比較を && ・ || ・ ! ・ 括弧で組み合わせて記述する
+//
+//	user!=U123 && date>=2026-01-01 && !text~"^<@"
+//
+// 使用できるフィールド:
+//   - user: ユーザーID
+//   - username: ユーザー名
+//   - date: 投稿日（YYYY-MM-DD、Config.TimeLocationのタイムゾーン）
+//   - text: 本文
+//   - len: 本文の文字数
+//   - thread: スレッドの親メッセージのタイムスタンプ
+//   - reply: スレッドへの返信であれば真（比較演算子なしで使用）
+//   - bot: Botによる投稿であれば真（比較演算子なしで使用。ユーザーIDがBで始まるかによる推定）
+//
+// 比較演算子は == != > >= < <= と正規表現の一致 ~ ・ 不一致 !~
+type Filter struct {
+	expr string
+	root filterNode
+	loc  *time.Location
+}
+
+// ParseFilter はフィルター式を解析する。日付はlocのタイムゾーンで判定する
+func ParseFilter(expr string, loc *time.Location) (*Filter, error) {
+	tokens, err := lexFilter(expr)
+	if err != nil {
+		return nil, fmt.Errorf("フィルター式の解析に失敗: %w", err)
+	}
+	p := &filterParser{tokens: tokens}
+	root, err := p.parseOr()
+	if err == nil && !p.done() {
+		err = fmt.Errorf("%d文字目に予期しない %q があります", p.peek().pos+1, p.peek().text)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("フィルター式の解析に失敗: %w", err)
+	}
+	if loc == nil {
+		loc = time.Local
+	}
+	return &Filter{expr: expr, root: root, loc: loc}, nil
+}
+
+// String は元のフィルター式を返す
+func (f *Filter) String() string {
+	return f.expr
+}
+
+// Match はメッセージがフィルター式を満たすかを判定
+func (f *Filter) Match(msg Message) bool {
+	return f.root.eval(msg, f.loc)
+}
+
+// FilterMessages はフィルター式を満たすメッセージだけを返す（fがnilの場合はそのまま返す）
+func FilterMessages(messages []Message, f *Filter) []Message {
+	if f == nil {
+		return messages
+	}
+	filtered := make([]Message, 0, len(messages))
+	for _, msg := range messages {
+		if f.Match(msg) {
+			filtered = append(filtered, msg)
+		}
+	}
+	return filtered
+}
+
+// IsReply はメッセージがスレッドへの返信かを判定
+func (m Message) IsReply() bool {
+	return m.ThreadTS != "" && m.ThreadTS != m.ID
+}
+
+// IsBot はメッセージがBotによる投稿かを判定
+// CSVにはBot情報がないため、ユーザーIDがBot ID（Bで始まる）の場合をBotとみなす推定にすぎない
+// ユーザーIDが空の場合（UserID列がないなど）は不明として、Botとはみなさない
+func (m Message) IsBot() bool {
+	return strings.HasPrefix(m.UserID, "B")
+}
+
+// filterNode はフィルター式の構文木のノード
+type filterNode interface {
+	eval(msg Message, loc *time.Location) bool
+}
+
+type (
+	andNode  struct{ left, right filterNode }
+	orNode   struct{ left, right filterNode }
+	notNode  struct{ operand filterNode }
+	boolNode struct{ field string }
+
+	compareNode struct {
+		field string
+		op    string
+		value string
+		num   int
+		re    *regexp.Regexp
+	}
+)
+
+func (n andNode) eval(msg Message, loc *time.Location) bool {
+	return n.left.eval(msg, loc) && n.right.eval(msg, loc)
+}
+
+func (n orNode) eval(msg Message, loc *time.Location) bool {
+	return n.left.eval(msg, loc) || n.right.eval(msg, loc)
+}
+
+func (n notNode) eval(msg Message, loc *time.Location) bool {
+	return !n.operand.eval(msg, loc)
+}
+
+func (n boolNode) eval(msg Message, _ *time.Location) bool {
+	switch n.field {
+	case "reply":
+		return msg.IsReply()
+	default:
+		return msg.IsBot()
+	}
+}
+
+func (n compareNode) eval(msg Message, loc *time.Location) bool {
+	var actual string
+	switch n.field {
+	case "user":
+		actual = msg.UserID
+	case "username":
+		actual = msg.Username
+	case "text":
+		actual = msg.Text
+	case "thread":
+		actual = msg.ThreadTS
+	case "date":
+		if msg.Timestamp.IsZero() {
+			return false
+		}
+		actual = msg.Timestamp.In(loc).Format("2006-01-02")
+	case "len":
+		return compareOrdered(utf8.RuneCountInString(msg.Text), n.num, n.op)
+	}
+
+	switch n.op {
+	case "~":
+		return n.re.MatchString(actual)
+	case "!~":
+		return !n.re.MatchString(actual)
+	default:
+		return compareOrdered(actual, n.value, n.op)
+	}
+}
+
+// compareOrdered は比較演算子で2つの値を比較
+func compareOrdered[T int | string](a, b T, op string) bool {
+	switch op {
+	case "==":
+		return a == b
+	case "!=":
+		return a != b
+	case ">":
+		return a > b
+	case ">=":
+		return a >= b
+	case "<":
+		return a < b
+	default:
+		return a <= b
+	}
+}
+
+// filterFields はフィールドが比較演算子を必要とするか（falseは真偽値フィールド）
+var filterFields = map[string]bool{
+	"user":     true,
+	"username": true,
+	"date":     true,
+	"text":     true,
+	"len":      true,
+	"thread":   true,
+	"reply":    false,
+	"bot":      false,
+}
+
+// filterToken はフィルター式の字句
+type filterToken struct {
+	kind string // "ident", "string", "op", "(", ")", "&&", "||", "!"
+	text string
+	pos  int
+}
+
+// lexFilter はフィルター式を字句に分割
+func lexFilter(expr string) ([]filterToken, error) {
+	var tokens []filterToken
+	for i := 0; i < len(expr); {
+		r, size := utf8.DecodeRuneInString(expr[i:])
+		rest := expr[i:]
+		switch {
+		case unicode.IsSpace(r):
+			i += size
+		case strings.HasPrefix(rest, "&&"), strings.HasPrefix(rest, "||"):
+			tokens = append(tokens, filterToken{kind: rest[:2], text: rest[:2], pos: i})
+			i += 2
+		case strings.HasPrefix(rest, "=="), strings.HasPrefix(rest, "!="),
+			strings.HasPrefix(rest, ">="), strings.HasPrefix(rest, "<="),
+			strings.HasPrefix(rest, "!~"):
+			tokens = append(tokens, filterToken{kind: "op", text: rest[:2], pos: i})
+			i += 2
+		case r == '>' || r == '<' || r == '~':
+			tokens = append(tokens, filterToken{kind: "op", text: string(r), pos: i})
+			i++
+		case r == '!' || r == '(' || r == ')':
+			tokens = append(tokens, filterToken{kind: string(r), text: string(r), pos: i})
+			i++
+		case r == '"':
+			s, n, err := unquoteFilterString(rest)
+			if err != nil {
+				return nil, fmt.Errorf("%d文字目: %w", i+1, err)
+			}
+			tokens = append(tokens, filterToken{kind: "string", text: s, pos: i})
+			i += n
+		default:
+			start := i
+			for i < len(expr) {
+				r, size := utf8.DecodeRuneInString(expr[i:])
+				if unicode.IsSpace(r) || strings.ContainsRune(`()!=<>~&|"`, r) {
+					break
+				}
+				i += size
+			}
+			if i == start {
+				return nil, fmt.Errorf("%d文字目に不正な文字 %q があります", i+1, r)
+			}
+			tokens = append(tokens, filterToken{kind: "ident", text: expr[start:i], pos: start})
+		}
+	}
+	return tokens, nil
+}
+
+// unquoteFilterString は二重引用符で囲まれた文字列を取り出し、消費したバイト数を返す
+// 文字列中では \" と \\ のみをエスケープとして扱い、正規表現の \d などはそのまま残す
+func unquoteFilterString(s string) (string, int, error) {
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\\' && i+1 < len(s) && (s[i+1] == '"' || s[i+1] == '\\'):
+			b.WriteByte(s[i+1])
+			i++
+		case c == '"':
+			return b.String(), i + 1, nil
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", 0, fmt.Errorf("文字列が閉じられていません")
+}
+
+// filterParser はフィルター式の再帰下降パーサー
+type filterParser struct {
+	tokens []filterToken
+	pos    int
+}
+
+func (p *filterParser) done() bool {
+	return p.pos >= len(p.tokens)
+}
+
+func (p *filterParser) peek() filterToken {
+	if p.done() {
+		return filterToken{kind: "eof"}
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *filterParser) next() filterToken {
+	t := p.peek()
+	p.pos++
+	return t
+}
+
+// parseOr は || で結合された式を解析
+func (p *filterParser) parseOr() (filterNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == "||" {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orNode{left, right}
+	}
+	return left, nil
+}
+
+// parseAnd は && で結合された式を解析
+func (p *filterParser) parseAnd() (filterNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == "&&" {
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = andNode{left, right}
+	}
+	return left, nil
+}
+
+// parseUnary は否定・括弧・比較を解析
+func (p *filterParser) parseUnary() (filterNode, error) {
+	switch t := p.next(); t.kind {
+	case "!":
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{operand}, nil
+	case "(":
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.next().kind != ")" {
+			return nil, fmt.Errorf("%d文字目の括弧が閉じられていません", t.pos+1)
+		}
+		return inner, nil
+	case "ident":
+		return p.parseComparison(t)
+	case "eof":
+		return nil, fmt.Errorf("式が途中で終わっています")
+	default:
+		return nil, fmt.Errorf("%d文字目に予期しない %q があります", t.pos+1, t.text)
+	}
+}
+
+// parseComparison はフィールドから始まる比較または真偽値フィールドを解析
+func (p *filterParser) parseComparison(field filterToken) (filterNode, error) {
+	name := strings.ToLower(field.text)
+	needsOp, ok := filterFields[name]
+	if !ok {
+		return nil, fmt.Errorf("%d文字目: 不明なフィールドです: %s", field.pos+1, field.text)
+	}
+	if !needsOp {
+		return boolNode{field: name}, nil
+	}
+
+	op := p.next()
+	if op.kind != "op" {
+		return nil, fmt.Errorf("%d文字目: %s の後に比較演算子が必要です", field.pos+1, field.text)
+	}
+	value := p.next()
+	if value.kind != "ident" && value.kind != "string" {
+		return nil, fmt.Errorf("%d文字目: %s の後に値が必要です", op.pos+1, op.text)
+	}
+
+	n := compareNode{field: name, op: op.text, value: value.text}
+	switch {
+	case op.text == "~" || op.text == "!~":
+		re, err := regexp.Compile(value.text)
+		if err != nil {
+			return nil, fmt.Errorf("%d文字目の正規表現が不正です: %w", value.pos+1, err)
+		}
+		n.re = re
+	case name == "len":
+		num, err := strconv.Atoi(value.text)
+		if err != nil {
+			return nil, fmt.Errorf("%d文字目: len には整数を指定してください", value.pos+1)
+		}
+		n.num = num
+	case name == "date":
+		if _, err := time.Parse("2006-01-02", value.text); err != nil {
+			return nil, fmt.Errorf("%d文字目: date はYYYY-MM-DD形式で指定してください", value.pos+1)
+		}
+	}
+	if name == "len" && n.re != nil {
+		return nil, fmt.Errorf("%d文字目: len に正規表現は使用できません", op.pos+1)
+	}
+	return n, nil
+}
@@ -0,0 +1,163 @@
+package wordcloud
+
+import (
+	"errors"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+// fuzzSeeds は縮退した入力のシード（コーパスは1行を1メッセージとする）
+var fuzzSeeds = []struct {
+	corpus   string
+	minCount uint8
+}{
+	{"", 1},            // 空のコーパス
+	{"\n\n", 1},        // 単語のないメッセージだけのコーパス
+	{"会議の資料を確認します", 5}, // 最小出現回数に達する単語がない
+	{"会議 資料 予定\n会議 資料 予定\n会議 資料 予定", 1}, // すべての単語が同じ出現回数
+	{"会議\n資料\n予定", 1},                   // 最大出現回数が1
+	{"障害 障害 障害 対応\n障害 対応\nリリース", 2},     // 通常の入力
+}
+
+var (
+	testAnalyzerOnce sync.Once
+	testAnalyzer     *Analyzer
+	testAnalyzerErr  error
+)
+
+// newTestAnalyzer はテスト間で共有するAnalyzerを返す（辞書の読み込みに時間がかかるため）
+func newTestAnalyzer(t testing.TB) *Analyzer {
+	t.Helper()
+	testAnalyzerOnce.Do(func() {
+		testAnalyzer, testAnalyzerErr = NewAnalyzer(WithNormalizers(DefaultNormalizers()...))
+	})
+	if testAnalyzerErr != nil {
+		t.Fatalf("アナライザーの初期化に失敗: %v", testAnalyzerErr)
+	}
+	return testAnalyzer
+}
+
+// fuzzConfig はファズテストで使う小さな画像の設定を返す
+func fuzzConfig(minCount uint8) Config {
+	config := defaultConfig()
+	config.MinCount = int(minCount%5) + 1
+	config.Width = 160
+	config.Height = 120
+	return config
+}
+
+// fuzzTexts はコーパスを1行1メッセージのテキストに分割する（空のコーパスはメッセージなし）
+func fuzzTexts(corpus string) []string {
+	if corpus == "" {
+		return nil
+	}
+	return strings.Split(corpus, "\n")
+}
+
+// checkGenerated は生成の結果が型付きのエラーか、設定の範囲に収まる単語であることを確かめる
+func checkGenerated(t *testing.T, config Config, texts []string, words []WordCount, err error) {
+	t.Helper()
+	if err != nil {
+		if len(texts) == 0 && !errors.Is(err, ErrNoMessages) {
+			t.Fatalf("メッセージがない場合はErrNoMessagesを返すべき: %v", err)
+		}
+		if !IsEmptyError(err) {
+			t.Fatalf("想定外のエラー: %v", err)
+		}
+		return
+	}
+	if len(words) == 0 {
+		t.Fatal("エラーなしで単語が空")
+	}
+	for _, word := range words {
+		if word.Count < config.MinCount {
+			t.Errorf("最小出現回数未満の単語: %+v", word)
+		}
+		if word.FontSize < config.MinFontSize || word.FontSize > config.MaxFontSize {
+			t.Errorf("フォントサイズが範囲外: %+v", word)
+		}
+	}
+}
+
+func FuzzAnalyze(f *testing.F) {
+	for _, seed := range fuzzSeeds {
+		f.Add(seed.corpus)
+	}
+	f.Add("<@U123> :tada: https://example.com `code` ｶﾀｶﾅ ラーメーン")
+
+	f.Fuzz(func(t *testing.T, text string) {
+		for _, token := range newTestAnalyzer(t).Analyze(text) {
+			if token.BaseForm == "" {
+				t.Errorf("基本形が空のトークン: %+v", token)
+			}
+			if token.Start < 0 || token.End < token.Start {
+				t.Errorf("位置が不正なトークン: %+v", token)
+			}
+		}
+	})
+}
+
+func FuzzGenerate(f *testing.F) {
+	for _, seed := range fuzzSeeds {
+		f.Add(seed.corpus, seed.minCount)
+	}
+
+	f.Fuzz(func(t *testing.T, corpus string, minCount uint8) {
+		config := fuzzConfig(minCount)
+		generator, err := NewGenerator(config, newTestAnalyzer(t))
+		if err != nil {
+			t.Fatalf("ジェネレーターの初期化に失敗: %v", err)
+		}
+		texts := fuzzTexts(corpus)
+		words, err := generator.Generate(texts)
+		checkGenerated(t, config, texts, words, err)
+	})
+}
+
+func FuzzExportPNG(f *testing.F) {
+	for _, seed := range fuzzSeeds {
+		f.Add(seed.corpus, seed.minCount)
+	}
+
+	fontPath := filepath.Join(f.TempDir(), "Go-Regular.ttf")
+	if err := os.WriteFile(fontPath, goregular.TTF, 0644); err != nil {
+		f.Fatalf("フォントの書き込みに失敗: %v", err)
+	}
+
+	f.Fuzz(func(t *testing.T, corpus string, minCount uint8) {
+		config := fuzzConfig(minCount)
+		config.FontPath = fontPath
+		processor, err := NewFileProcessor(config, newTestAnalyzer(t))
+		if err != nil {
+			t.Fatalf("プロセッサーの初期化に失敗: %v", err)
+		}
+
+		// 単語がない場合も空のデータで画像を出力できる
+		texts := fuzzTexts(corpus)
+		words, err := processor.Generator().Generate(texts)
+		checkGenerated(t, config, texts, words, err)
+
+		output := filepath.Join(t.TempDir(), "wordcloud.png")
+		if err := processor.ExportPNG(words, output); err != nil {
+			t.Fatalf("PNG画像の出力に失敗: %v", err)
+		}
+		file, err := os.Open(output)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer file.Close()
+		img, err := png.Decode(file)
+		if err != nil {
+			t.Fatalf("出力したPNG画像を読み込めない: %v", err)
+		}
+		if size := img.Bounds().Size(); size.X != config.Width || size.Y != config.Height {
+			t.Errorf("画像のサイズが %v（期待値 %dx%d）", size, config.Width, config.Height)
+		}
+	})
+}
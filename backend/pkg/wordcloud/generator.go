@@ -2,67 +2,104 @@ package wordcloud
 
 import (
 	"fmt"
-	"log"
-	"math"
+	"log/slog"
 	"sort"
+	"strconv"
+	"time"
 )
 
 // Generator はワードクラウドのデータを生成する構造体
 type Generator struct {
 	config   Config
 	analyzer *Analyzer
+	logger   *slog.Logger
 }
 
 // NewGenerator は新しいGeneratorを作成
-func NewGenerator(config Config, analyzer *Analyzer) *Generator {
+// analyzerがnilの場合は既定の設定のAnalyzerを作成する
+func NewGenerator(config Config, analyzer *Analyzer, options ...GeneratorOption) (*Generator, error) {
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
 	if analyzer == nil {
 		var err error
 		analyzer, err = NewAnalyzer()
 		if err != nil {
-			panic(err) // 実際のアプリケーションではエラーハンドリングを適切に行う
+			return nil, fmt.Errorf("アナライザーの初期化に失敗: %w", err)
 		}
 	}
 
-	return &Generator{
+	g := &Generator{
 		config:   config,
 		analyzer: analyzer,
+		logger:   discardLogger,
+	}
+	for _, opt := range options {
+		opt(g)
 	}
+	return g, nil
 }
 
 // Generate はテキストからワードクラウドデータを生成
 func (g *Generator) Generate(texts []string) ([]WordCount, error) {
-	log.Printf("テキスト解析を開始します（%d件）...", len(texts))
-
-	// 単語のカウント
-	wordCounts := make(map[string]int)
+	messages := make([]Message, len(texts))
 	for i, text := range texts {
-		tokens := g.analyzer.Analyze(text)
-		for _, token := range tokens {
-			wordCounts[token.BaseForm]++
-		}
+		messages[i] = Message{ID: strconv.Itoa(i), Row: i + 1, Text: text}
+	}
+	return g.GenerateMessages(messages)
+}
 
-		// 1000件ごとに進捗を表示
-		if (i+1)%1000 == 0 {
-			log.Printf("テキスト解析中... %d/%d件完了", i+1, len(texts))
-		}
+// GenerateMessages はメッセージからワードクラウドデータを生成
+// TF-IDFで文書をスレッドや日付単位にまとめる場合はメッセージのメタデータを使用する
+// メッセージがない場合はErrNoMessages、最小出現回数に達した単語がない場合はErrNoWordsを返す
+func (g *Generator) GenerateMessages(messages []Message, options ...GenerateOption) ([]WordCount, error) {
+	opts := newGenerateOptions(options)
+	if err := g.config.validateWeighting(); err != nil {
+		return nil, err
+	}
+	if len(messages) == 0 {
+		return nil, ErrNoMessages
 	}
 
-	log.Printf("単語の出現回数集計が完了しました。%d個の一意な単語が見つかりました。", len(wordCounts))
+	stats := g.countWords(messages, opts.progress)
 
 	// WordCountのスライスに変換
 	var counts []WordCount
-	for word, count := range wordCounts {
+	for word, count := range stats.counts {
 		if count >= g.config.MinCount {
 			counts = append(counts, WordCount{
-				Text:  word,
-				Count: count,
+				Text:     word,
+				Count:    count,
+				Variants: mergedVariants(word, stats.variants[word]),
+				POS:      mostFrequent(stats.pos[word]),
+				User:     mostFrequent(stats.users[word]),
 			})
 		}
 	}
 
-	// 出現回数でソート
+	// 上位のフレーズを単語と同列に扱う
+	if stats.phrases != nil {
+		counts = append(counts, stats.phrases.top(g.config.MinCount, g.config.MaxPhrases)...)
+	}
+
+	// 重み付けのスコアと平均感情スコアを計算
+	for i := range counts {
+		if stats.sentiment != nil {
+			counts[i].Sentiment = stats.sentiment[counts[i].Text] / float64(counts[i].Count)
+		}
+		switch g.config.Weighting {
+		case WeightingTFIDF:
+			counts[i].Score = stats.df.tfidf(counts[i].Text, counts[i].Count)
+		case WeightingLogLikelihood:
+			ref := g.config.Reference
+			counts[i].Score = logLikelihood(counts[i].Count, stats.total, ref.Counts[counts[i].Text], ref.Total)
+		}
+	}
+
+	// 重み付けの値でソート
+	weighting := g.config.Weighting
 	sort.Slice(counts, func(i, j int) bool {
-		return counts[i].Count > counts[j].Count
+		return counts[i].value(weighting) > counts[j].value(weighting)
 	})
 
 	// 最大単語数に制限
@@ -70,42 +107,144 @@ func (g *Generator) Generate(texts []string) ([]WordCount, error) {
 		counts = counts[:g.config.MaxWords]
 	}
 
+	if len(counts) == 0 {
+		return nil, fmt.Errorf("%w（最小出現回数: %d）", ErrNoWords, g.config.MinCount)
+	}
+
 	// フォントサイズと色を計算
-	maxCount := counts[0].Count
-	for i := range counts {
-		counts[i].FontSize = g.calculateFontSize(counts[i].Count, maxCount)
-		counts[i].Color = g.calculateColor(counts[i].Count, maxCount)
+	value := func(wc WordCount) float64 { return wc.value(weighting) }
+	if err := g.scaleFontSizes(counts, g.config.fontScale(), value); err != nil {
+		return nil, err
+	}
+	if err := g.applyColors(counts, value); err != nil {
+		return nil, err
 	}
 
 	return counts, nil
 }
 
-// calculateFontSize は出現回数からフォントサイズを計算
-func (g *Generator) calculateFontSize(count, maxCount int) int {
-	ratio := math.Log(float64(count)) / math.Log(float64(maxCount))
-	size := g.config.MinFontSize + int(ratio*float64(g.config.MaxFontSize-g.config.MinFontSize))
+// wordStats はメッセージ群から集計した単語の統計
+type wordStats struct {
+	counts    map[string]int            // 単語ごとの出現回数
+	variants  map[string]map[string]int // 同義語として統合された表記ごとの出現回数
+	phrases   *phraseCounter            // フレーズの集計（無効な場合はnil）
+	df        *documentFrequency        // 文書頻度（TF-IDF以外ではnil）
+	total     int                       // 全単語の出現回数の合計
+	sentiment map[string]float64        // 単語が出現したメッセージの感情スコアの合計（無効な場合はnil）
+	pos       map[string]map[string]int // 単語ごとの品詞の出現回数
+	users     map[string]map[string]int // 単語ごとのユーザーの使用回数
+}
+
+// countWords はメッセージを解析して単語の出現回数を集計し、解析の進捗をprogressに通知する（nilの場合は通知しない）
+// Config.ExcludeUsersに含まれるユーザーのメッセージは集計しない
+func (g *Generator) countWords(messages []Message, progress Progress) *wordStats {
+	messages = ExcludeUsers(messages, g.config.ExcludeUsers...)
+	g.logger.Debug("テキスト解析を開始します", "messages", len(messages))
+	start := time.Now()
+
+	stats := &wordStats{
+		counts:   make(map[string]int),
+		variants: make(map[string]map[string]int),
+		pos:      make(map[string]map[string]int),
+		users:    make(map[string]map[string]int),
+	}
+	if g.config.NGramSize >= 2 {
+		stats.phrases = newPhraseCounter(g.config.NGramSize)
+	}
+	if g.config.Weighting == WeightingTFIDF {
+		stats.df = newDocumentFrequency()
+	}
+	lexicon := g.config.sentimentLexicon()
+	if lexicon != nil {
+		stats.sentiment = make(map[string]float64)
+	}
+	reporter := newProgressReporter(progress, StageAnalyze, len(messages))
+
+	for i, msg := range messages {
+		doc := g.config.documentKey(msg)
+		score := 0.0
+		if lexicon != nil {
+			score = lexicon.Score(g.analyzer.Morphemes(msg.Text))
+		}
+		for _, tokens := range g.analyzeSentences(msg.Text) {
+			for _, token := range tokens {
+				stats.counts[token.BaseForm]++
+				countKey(stats.pos, token.BaseForm, token.POS)
+				if msg.UserID != "" {
+					countKey(stats.users, token.BaseForm, msg.UserID)
+				}
+				if stats.sentiment != nil {
+					stats.sentiment[token.BaseForm] += score
+				}
+				stats.total++
+				if _, ok := g.config.Synonyms.Canonical(token.Surface); ok {
+					if stats.variants[token.BaseForm] == nil {
+						stats.variants[token.BaseForm] = make(map[string]int)
+					}
+					stats.variants[token.BaseForm][token.Surface]++
+				}
+				if stats.df != nil {
+					stats.df.add(doc, token.BaseForm)
+				}
+			}
+			if stats.phrases != nil {
+				for _, phrase := range stats.phrases.add(tokens) {
+					if stats.sentiment != nil {
+						stats.sentiment[phrase] += score
+					}
+					if stats.df != nil {
+						stats.df.add(doc, phrase)
+					}
+				}
+			}
+		}
+
+		reporter.report(i + 1)
+	}
+
+	g.logger.Info("単語の出現回数の集計が完了しました", "messages", len(messages), "words", len(stats.counts), "duration", time.Since(start))
+	return stats
+}
+
+// analyzeSentences はテキストを解析し、文ごとのトークン列を返す
+// フレーズを集計しない場合は文に分割せずテキスト全体を1つとして扱う
+func (g *Generator) analyzeSentences(text string) [][]Token {
+	if g.config.NGramSize < 2 {
+		return [][]Token{g.config.Synonyms.foldSynonyms(g.analyzer.Analyze(text))}
+	}
 
-	if size < g.config.MinFontSize {
-		return g.config.MinFontSize
+	sentences := g.analyzer.analyzeSentences(text)
+	for i, tokens := range sentences {
+		sentences[i] = g.config.Synonyms.foldSynonyms(tokens)
 	}
-	if size > g.config.MaxFontSize {
-		return g.config.MaxFontSize
+	return sentences
+}
+
+// countKey は単語ごとの値の出現回数を数える
+func countKey(counts map[string]map[string]int, word, key string) {
+	if counts[word] == nil {
+		counts[word] = make(map[string]int)
 	}
-	return size
+	counts[word][key]++
 }
 
-// calculateColor は出現回数から色を計算
-func (g *Generator) calculateColor(count, maxCount int) string {
-	ratio := float64(count) / float64(maxCount)
+// mostFrequent は出現回数が最も多い値を返す（同数の場合は辞書順で先のもの）
+func mostFrequent(counts map[string]int) string {
+	best, bestCount := "", 0
+	for key, count := range counts {
+		if count > bestCount || (count == bestCount && key < best) {
+			best, bestCount = key, count
+		}
+	}
+	return best
+}
 
-	switch g.config.ColorScheme {
-	case "blue":
-		intensity := uint8(150 + int(105*ratio))
-		return fmt.Sprintf("#0000%02x", intensity)
-	case "rainbow":
-		hue := int(240 * ratio)
-		return fmt.Sprintf("hsl(%d, 70%%, 50%%)", hue)
-	default:
-		return "#000000"
+// mergedVariants は同義語として統合された表記がある場合のみ表記ごとの出現回数を返す
+func mergedVariants(word string, variants map[string]int) map[string]int {
+	for variant := range variants {
+		if variant != word {
+			return variants
+		}
 	}
+	return nil
 }
@@ -0,0 +1,78 @@
+package wordcloud
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// markupMessage はコードブロックとSlackの記法を含むメッセージ
+// 文の区切りとみなす文字（改行・!・?）が記法の内側にある
+const markupMessage = "障害対応の手順です <https://example.com/runbook?q=restart|リンク> <!here>\n" +
+	"```\nkubectl rollout restart deploy/api\nkubectl get pods\n```\n" +
+	"障害対応を確認しました！"
+
+// markupFragments は正規化で取り除かれるべき記法やコードの断片
+var markupFragments = []string{"kubectl", "rollout", "pods", "`", "<", ">", "|", "=", "://", "here", "example", "runbook"}
+
+// checkNoMarkup は単語に記法やコードの断片が含まれないことを確かめる
+func checkNoMarkup(t *testing.T, words []WordCount) {
+	t.Helper()
+	for _, word := range words {
+		for _, fragment := range markupFragments {
+			if strings.Contains(word.Text, fragment) {
+				t.Errorf("記法やコードの断片が単語に含まれる: %q", word.Text)
+			}
+		}
+	}
+}
+
+func TestGenerateNormalizesBeforeSplittingSentences(t *testing.T) {
+	for _, ngram := range []int{1, 2} {
+		config := defaultConfig()
+		config.MinCount = 1
+		config.NGramSize = ngram
+		generator, err := NewGenerator(config, newTestAnalyzer(t))
+		if err != nil {
+			t.Fatalf("ジェネレーターの初期化に失敗: %v", err)
+		}
+
+		words, err := generator.Generate([]string{markupMessage})
+		if err != nil {
+			t.Fatalf("NGramSize=%d: Generate() error = %v", ngram, err)
+		}
+		checkNoMarkup(t, words)
+
+		found := map[string]bool{}
+		for _, word := range words {
+			found[word.Text] = true
+		}
+		for _, want := range []string{"障害", "対応", "リンク"} {
+			if !found[want] {
+				t.Errorf("NGramSize=%d: 単語 %q がない: %v", ngram, want, words)
+			}
+		}
+	}
+}
+
+func TestDocumentKeyDayWithoutTimestamp(t *testing.T) {
+	config := defaultConfig()
+	config.DocumentUnit = DocumentDay
+	config.TimeLocation = time.UTC
+	day := time.Date(2026, 1, 2, 3, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		msg  Message
+		want string
+	}{
+		{msg: Message{Row: 2, Timestamp: day}, want: "2026-01-02"},
+		{msg: Message{Row: 3, Timestamp: day.Add(time.Hour)}, want: "2026-01-02"},
+		{msg: Message{Row: 4}, want: "row:4"},
+		{msg: Message{Row: 5}, want: "row:5"},
+	}
+	for _, tt := range tests {
+		if got := config.documentKey(tt.msg); got != tt.want {
+			t.Errorf("documentKey(row %d) = %q, want %q", tt.msg.Row, got, tt.want)
+		}
+	}
+}
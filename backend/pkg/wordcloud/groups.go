@@ -0,0 +1,221 @@
+package wordcloud
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// GroupMapping はユーザーIDからグループ（チーム）名への対応表
+type GroupMapping map[string]string
+
+// ParseGroupMapping は "ユーザーID,グループ名" 形式のCSVから対応表を読み込む
+// "#" で始まる行はコメントとして扱う
+func ParseGroupMapping(r io.Reader) (GroupMapping, error) {
+	reader := csv.NewReader(r)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	mapping := make(GroupMapping)
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("グループ対応表の読み込みに失敗: %w", err)
+		}
+		if len(record) < 2 {
+			line, _ := reader.FieldPos(0)
+			return nil, fmt.Errorf("%d行目の形式が不正です: %q", line, strings.Join(record, ","))
+		}
+		user, group := strings.TrimSpace(record[0]), strings.TrimSpace(record[1])
+		if user != "" && group != "" {
+			mapping[user] = group
+		}
+	}
+	return mapping, nil
+}
+
+// LoadGroupMapping はファイルからグループ対応表を読み込む
+func LoadGroupMapping(path string) (GroupMapping, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("グループ対応表のオープンに失敗: %w", err)
+	}
+	defer file.Close()
+
+	mapping, err := ParseGroupMapping(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return mapping, nil
+}
+
+// GroupOptions はグループごとのワードクラウド生成の設定
+type GroupOptions struct {
+	Mapping       GroupMapping // ユーザーからグループへの対応表（nilの場合はユーザーごと）
+	UnmappedGroup string       // 対応表にないユーザーのグループ名（空の場合は除外）
+}
+
+// groupOf はメッセージが属するグループ名を返す
+func (o GroupOptions) groupOf(msg Message) string {
+	if o.Mapping == nil {
+		return msg.UserID
+	}
+	if group, ok := o.Mapping[msg.UserID]; ok {
+		return group
+	}
+	return o.UnmappedGroup
+}
+
+// ExcludeUsers はユーザーIDまたはユーザー名が一致するメッセージを取り除く
+func ExcludeUsers(messages []Message, users ...string) []Message {
+	if len(users) == 0 {
+		return messages
+	}
+
+	excluded := make(map[string]bool, len(users))
+	for _, user := range users {
+		if user = strings.TrimSpace(user); user != "" {
+			excluded[user] = true
+		}
+	}
+
+	filtered := make([]Message, 0, len(messages))
+	for _, msg := range messages {
+		if excluded[msg.UserID] || (msg.Username != "" && excluded[msg.Username]) {
+			continue
+		}
+		filtered = append(filtered, msg)
+	}
+	return filtered
+}
+
+// GroupCloud は1つのグループのワードクラウド
+type GroupCloud struct {
+	Group    string      `json:"group"`           // グループ名（ユーザーごとの場合はユーザーID）
+	Users    []string    `json:"users"`           // グループに含まれるユーザーID
+	Messages int         `json:"messages"`        // メッセージ数
+	Words    []WordCount `json:"words,omitempty"` // ワードクラウドデータ
+	Image    string      `json:"image,omitempty"` // 出力したPNG画像のファイル名
+	Data     string      `json:"data,omitempty"`  // 出力したJSONファイルのファイル名
+}
+
+// GenerateGroups はメッセージをユーザーまたはグループごとに分けてワードクラウドデータを生成
+// Config.ExcludeUsersに含まれるユーザーは除外し、結果はメッセージ数の多い順に並ぶ
+func (g *Generator) GenerateGroups(messages []Message, opts GroupOptions) ([]GroupCloud, error) {
+	messages = ExcludeUsers(messages, g.config.ExcludeUsers...)
+
+	grouped := make(map[string][]Message)
+	users := make(map[string]map[string]bool)
+	for _, msg := range messages {
+		group := opts.groupOf(msg)
+		if group == "" {
+			continue
+		}
+		grouped[group] = append(grouped[group], msg)
+		if users[group] == nil {
+			users[group] = make(map[string]bool)
+		}
+		users[group][msg.UserID] = true
+	}
+
+	clouds := make([]GroupCloud, 0, len(grouped))
+	for group, msgs := range grouped {
+		// 単語が最小出現回数に達しないグループは空のワードクラウドとする
+		words, err := g.GenerateMessages(msgs)
+		if IsEmptyError(err) {
+			words, err = nil, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("グループ %s のワードクラウド生成に失敗: %w", group, err)
+		}
+		cloud := GroupCloud{Group: group, Messages: len(msgs), Words: words}
+		for user := range users[group] {
+			cloud.Users = append(cloud.Users, user)
+		}
+		sort.Strings(cloud.Users)
+		clouds = append(clouds, cloud)
+	}
+
+	sort.Slice(clouds, func(i, j int) bool {
+		if clouds[i].Messages != clouds[j].Messages {
+			return clouds[i].Messages > clouds[j].Messages
+		}
+		return clouds[i].Group < clouds[j].Group
+	})
+	return clouds, nil
+}
+
+// unsafeFileChars はファイル名に使用しない文字
+var unsafeFileChars = regexp.MustCompile(`[^\p{L}\p{N}._-]+`)
+
+// groupFileName はグループ名からファイル名に使える文字列を作成
+func groupFileName(group string) string {
+	name := strings.Trim(unsafeFileChars.ReplaceAllString(group, "_"), "_.")
+	if name == "" {
+		return "group"
+	}
+	return name
+}
+
+// ExportGroups はCSVファイルのメッセージをグループごとのワードクラウドとしてoutputDirに出力
+// グループごとのPNG画像とJSONファイルに加え、一覧をindex.jsonに出力する
+func (fp *FileProcessor) ExportGroups(inputPath string, messageColumn int, outputDir string, opts GroupOptions) ([]GroupCloud, error) {
+	messages, err := fp.ReadCSV(inputPath, messageColumn)
+	if err != nil {
+		return nil, err
+	}
+
+	clouds, err := fp.generator.GenerateGroups(messages, opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return nil, fmt.Errorf("出力ディレクトリの作成に失敗: %w", err)
+	}
+
+	used := make(map[string]int)
+	for i := range clouds {
+		cloud := &clouds[i]
+		if len(cloud.Words) == 0 {
+			fp.generator.logger.Warn("出現回数の条件を満たす単語がないグループがあります", "group", cloud.Group)
+			continue
+		}
+
+		name := groupFileName(cloud.Group)
+		if n := used[name]; n > 0 {
+			name = fmt.Sprintf("%s_%d", name, n+1)
+		}
+		used[groupFileName(cloud.Group)]++
+
+		cloud.Image = name + ".png"
+		cloud.Data = name + ".json"
+		if err := fp.ExportPNG(cloud.Words, filepath.Join(outputDir, cloud.Image)); err != nil {
+			return nil, fmt.Errorf("グループ %s の画像出力に失敗: %w", cloud.Group, err)
+		}
+		if err := fp.ExportJSON(cloud.Words, filepath.Join(outputDir, cloud.Data)); err != nil {
+			return nil, fmt.Errorf("グループ %s のJSON出力に失敗: %w", cloud.Group, err)
+		}
+	}
+
+	// 一覧には単語データを含めない
+	index := make([]GroupCloud, len(clouds))
+	for i, cloud := range clouds {
+		cloud.Words = nil
+		index[i] = cloud
+	}
+	if err := writeJSON(index, filepath.Join(outputDir, "index.json")); err != nil {
+		return nil, err
+	}
+
+	fp.generator.logger.Info("グループごとのワードクラウドの出力が完了しました", "dir", outputDir, "groups", len(clouds))
+	return clouds, nil
+}
@@ -0,0 +1,212 @@
+package wordcloud
+
+import (
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// MessageRef は単語が出現したメッセージへの参照
+type MessageRef struct {
+	Row       int       `json:"row"`                // 入力での行番号
+	Timestamp time.Time `json:"timestamp"`          // 投稿日時
+	UserID    string    `json:"userId,omitempty"`   // 投稿者のユーザーID
+	Username  string    `json:"username,omitempty"` // 投稿者の表示名
+	ThreadTS  string    `json:"threadTs,omitempty"` // スレッドの親メッセージのタイムスタンプ
+	Text      string    `json:"text"`               // 本文
+	Count     int       `json:"count"`              // メッセージ中の出現回数
+	Surfaces  []string  `json:"surfaces,omitempty"` // メッセージ中での表記
+}
+
+// WordIndex は単語（基本形）から出現したメッセージへの逆引き索引
+type WordIndex struct {
+	refs map[string][]MessageRef
+}
+
+// newWordIndex は空の索引を作成
+func newWordIndex() *WordIndex {
+	return &WordIndex{refs: make(map[string][]MessageRef)}
+}
+
+// add はメッセージ中の単語の出現を索引に追加する
+// 同じメッセージの出現はまとめて1つの参照にする
+func (idx *WordIndex) add(msg Message, word, surface string) {
+	refs := idx.refs[word]
+	if n := len(refs); n > 0 && refs[n-1].Row == msg.Row && refs[n-1].Text == msg.Text {
+		ref := &refs[n-1]
+		ref.Count++
+		if !containsString(ref.Surfaces, surface) {
+			ref.Surfaces = append(ref.Surfaces, surface)
+		}
+		return
+	}
+	idx.refs[word] = append(refs, MessageRef{
+		Row:       msg.Row,
+		Timestamp: msg.Timestamp,
+		UserID:    msg.UserID,
+		Username:  msg.Username,
+		ThreadTS:  msg.ThreadTS,
+		Text:      msg.Text,
+		Count:     1,
+		Surfaces:  []string{surface},
+	})
+}
+
+// containsString はスライスに文字列が含まれるかを判定
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Len は索引に含まれる単語数を返す
+func (idx *WordIndex) Len() int {
+	return len(idx.refs)
+}
+
+// Lookup は単語が出現したメッセージを入力の順序で返す
+func (idx *WordIndex) Lookup(word string) []MessageRef {
+	return idx.refs[word]
+}
+
+// Sample は単語の出現回数が多いメッセージから最大limit件を返す（0以下は全件）
+func (idx *WordIndex) Sample(word string, limit int) []MessageRef {
+	refs := append([]MessageRef(nil), idx.refs[word]...)
+	sort.SliceStable(refs, func(i, j int) bool {
+		return refs[i].Count > refs[j].Count
+	})
+	if limit > 0 && len(refs) > limit {
+		refs = refs[:limit]
+	}
+	return refs
+}
+
+// KWICLine はKWIC（Keyword in Context）形式の1行
+type KWICLine struct {
+	Ref     MessageRef `json:"ref"`     // 出現したメッセージ
+	Left    string     `json:"left"`    // 単語の前の文脈
+	Keyword string     `json:"keyword"` // メッセージ中での単語の表記
+	Right   string     `json:"right"`   // 単語の後の文脈
+}
+
+// Concordance は単語の出現箇所を前後width文字の文脈とともに最大limit件返す（0以下は全件）
+// 改行は空白に置き換え、本文中に表記が見つからない場合はNFKC正規化した本文から探す
+func (idx *WordIndex) Concordance(word string, width, limit int) []KWICLine {
+	var lines []KWICLine
+	for _, ref := range idx.refs[word] {
+		text := strings.Join(strings.Fields(ref.Text), " ")
+		found := kwicLines(ref, text, width)
+		if len(found) == 0 {
+			found = kwicLines(ref, norm.NFKC.String(text), width)
+		}
+		for _, line := range found {
+			if limit > 0 && len(lines) >= limit {
+				return lines
+			}
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// kwicLines はテキスト中の表記の出現箇所をKWICの行にする
+func kwicLines(ref MessageRef, text string, width int) []KWICLine {
+	var lines []KWICLine
+	for offset := 0; offset < len(text); {
+		// 最も手前に出現する表記を探す
+		pos, keyword := -1, ""
+		for _, surface := range ref.Surfaces {
+			if surface == "" {
+				continue
+			}
+			if i := indexFold(text[offset:], surface); i >= 0 && (pos < 0 || i < pos) {
+				pos, keyword = i, text[offset+i:offset+i+len(surface)]
+			}
+		}
+		if pos < 0 {
+			break
+		}
+		start := offset + pos
+		end := start + len(keyword)
+		lines = append(lines, KWICLine{
+			Ref:     ref,
+			Left:    lastRunes(text[:start], width),
+			Keyword: keyword,
+			Right:   firstRunes(text[end:], width),
+		})
+		offset = end
+	}
+	return lines
+}
+
+// indexFold は大文字と小文字を区別せずに部分文字列の位置を返す
+// 大文字・小文字の変換でバイト長が変わる文字は考慮せず、表記と同じバイト長の範囲を比較する
+func indexFold(s, substr string) int {
+	if i := strings.Index(s, substr); i >= 0 {
+		return i
+	}
+	for i := 0; i+len(substr) <= len(s); i++ {
+		if strings.EqualFold(s[i:i+len(substr)], substr) {
+			return i
+		}
+	}
+	return -1
+}
+
+// lastRunes は文字列の末尾n文字を返す
+func lastRunes(s string, n int) string {
+	if n <= 0 {
+		return ""
+	}
+	for i := len(s); i > 0; {
+		_, size := utf8.DecodeLastRuneInString(s[:i])
+		i -= size
+		if n--; n == 0 {
+			return s[i:]
+		}
+	}
+	return s
+}
+
+// firstRunes は文字列の先頭n文字を返す
+func firstRunes(s string, n int) string {
+	for i := range s {
+		if n == 0 {
+			return s[:i]
+		}
+		n--
+	}
+	return s
+}
+
+// BuildIndex はメッセージを解析して単語（基本形）から出現したメッセージへの逆引き索引を作成する
+// 集計と同じくConfig.ExcludeUsersのユーザーのメッセージは含めず、同義語は正規形にまとめる
+func (g *Generator) BuildIndex(messages []Message) *WordIndex {
+	index := newWordIndex()
+	for _, msg := range ExcludeUsers(messages, g.config.ExcludeUsers...) {
+		for _, tokens := range g.analyzeSentences(msg.Text) {
+			for _, token := range tokens {
+				index.add(msg, token.BaseForm, token.Surface)
+			}
+		}
+	}
+	return index
+}
+
+// ResolveWord は検索語を索引のキー（同義語の正規形または基本形）に変換する
+func (g *Generator) ResolveWord(word string) string {
+	word = strings.TrimSpace(word)
+	if canonical, ok := g.config.Synonyms.Canonical(word); ok {
+		return canonical
+	}
+	if tokens := g.analyzer.Analyze(word); len(tokens) == 1 {
+		return tokens[0].BaseForm
+	}
+	return word
+}
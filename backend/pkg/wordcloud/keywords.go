@@ -0,0 +1,307 @@
+package wordcloud
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// キーワード抽出の方式
+const (
+	KeywordTextRank = "textrank" // 単語の共起グラフのTextRank
+	KeywordRAKE     = "rake"     // 連続する名詞を候補とするRAKE
+)
+
+// KeywordOptions はキーワード抽出の設定
+type KeywordOptions struct {
+	Method      string  // 抽出方式（textrank/rake）
+	Window      int     // TextRankで共起とみなす単語間の距離
+	Damping     float64 // TextRankの減衰係数
+	Iterations  int     // TextRankの最大反復回数
+	MaxKeywords int     // 出力するキーワードの最大数（0以下はConfig.MaxWords）
+}
+
+// DefaultKeywordOptions はデフォルトのキーワード抽出設定を返す
+func DefaultKeywordOptions() KeywordOptions {
+	return KeywordOptions{
+		Method:     KeywordTextRank,
+		Window:     2,
+		Damping:    0.85,
+		Iterations: 100,
+	}
+}
+
+// textRankTolerance はTextRankの反復を打ち切るスコアの変化量
+const textRankTolerance = 1e-6
+
+// Keywords はメッセージから重要なキーワード（フレーズ）を抽出し、スコアの高い順に返す
+// 結果のScoreに抽出方式のスコア、Countに出現回数が入り、そのままExportPNGに渡せる
+func (g *Generator) Keywords(messages []Message, opts KeywordOptions) ([]WordCount, error) {
+	messages = ExcludeUsers(messages, g.config.ExcludeUsers...)
+
+	var keywords []WordCount
+	switch opts.Method {
+	case KeywordTextRank:
+		keywords = g.textRank(messages, opts)
+	case KeywordRAKE:
+		keywords = g.rake(messages)
+	default:
+		return nil, fmt.Errorf("不明なキーワード抽出方式です: %s", opts.Method)
+	}
+
+	sort.Slice(keywords, func(i, j int) bool {
+		if keywords[i].Score != keywords[j].Score {
+			return keywords[i].Score > keywords[j].Score
+		}
+		return keywords[i].Text < keywords[j].Text
+	})
+	limit := opts.MaxKeywords
+	if limit <= 0 {
+		limit = g.config.MaxWords
+	}
+	if len(keywords) > limit {
+		keywords = keywords[:limit]
+	}
+
+	if len(keywords) == 0 {
+		return nil, fmt.Errorf("%w（最小出現回数: %d）", ErrNoWords, g.config.MinCount)
+	}
+
+	// スコアは出現回数ではないため、割り当て方式が未指定の場合は線形に割り当てる
+	score := func(wc WordCount) float64 { return wc.Score }
+	if err := g.scaleFontSizes(keywords, cmp.Or(g.config.FontScale, FontScaleLinear), score); err != nil {
+		return nil, err
+	}
+	if err := g.applyColors(keywords, score); err != nil {
+		return nil, err
+	}
+
+	g.logger.Info("キーワードの抽出が完了しました", "method", opts.Method, "count", len(keywords))
+	return keywords, nil
+}
+
+// sentenceTokens はメッセージを正規化してから文に分けて、同義語を統合したトークン列を返す
+func (g *Generator) sentenceTokens(text string) [][]Token {
+	var sentences [][]Token
+	for _, tokens := range g.analyzer.analyzeSentences(text) {
+		if tokens := g.config.Synonyms.foldSynonyms(tokens); len(tokens) > 0 {
+			sentences = append(sentences, tokens)
+		}
+	}
+	return sentences
+}
+
+// textRank は文中でwindow以内に並ぶ単語を結んだグラフのTextRankで単語を順位付けし、
+// 上位の単語が本文中で隣接する箇所をフレーズとしてまとめる
+func (g *Generator) textRank(messages []Message, opts KeywordOptions) []WordCount {
+	window := max(opts.Window, 2)
+
+	var sentences [][]Token
+	counts := make(map[string]int)
+	edges := make(map[string]map[string]float64)
+	link := func(a, b string) {
+		if edges[a] == nil {
+			edges[a] = make(map[string]float64)
+		}
+		edges[a][b]++
+	}
+	for _, msg := range messages {
+		for _, tokens := range g.sentenceTokens(msg.Text) {
+			sentences = append(sentences, tokens)
+			for i, token := range tokens {
+				counts[token.BaseForm]++
+				for j := i + 1; j < len(tokens) && j-i < window; j++ {
+					if a, b := token.BaseForm, tokens[j].BaseForm; a != b {
+						link(a, b)
+						link(b, a)
+					}
+				}
+			}
+		}
+	}
+
+	// 出現回数が最小出現回数以上の単語をグラフのノードとする
+	rank := make(map[string]float64)
+	for word, count := range counts {
+		if count >= g.config.MinCount {
+			rank[word] = 1
+		}
+	}
+	outWeight := make(map[string]float64)
+	for a := range rank {
+		for b, w := range edges[a] {
+			if _, ok := rank[b]; ok {
+				outWeight[a] += w
+			}
+		}
+	}
+
+	damping := opts.Damping
+	if damping <= 0 || damping >= 1 {
+		damping = 0.85
+	}
+	for iter := 0; iter < max(opts.Iterations, 1); iter++ {
+		next := make(map[string]float64, len(rank))
+		diff := 0.0
+		for v := range rank {
+			sum := 0.0
+			for u, w := range edges[v] {
+				if _, ok := rank[u]; ok && outWeight[u] > 0 {
+					sum += w / outWeight[u] * rank[u]
+				}
+			}
+			next[v] = (1 - damping) + damping*sum
+			diff = math.Max(diff, math.Abs(next[v]-rank[v]))
+		}
+		rank = next
+		if diff < textRankTolerance {
+			break
+		}
+	}
+
+	// 上位3分の1の単語をキーワードとし、本文中で隣接するキーワードを1つのフレーズにまとめる
+	ranked := make([]string, 0, len(rank))
+	for word := range rank {
+		ranked = append(ranked, word)
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if rank[ranked[i]] != rank[ranked[j]] {
+			return rank[ranked[i]] > rank[ranked[j]]
+		}
+		return ranked[i] < ranked[j]
+	})
+	top := make(map[string]bool)
+	for _, word := range ranked[:(len(ranked)+2)/3] {
+		top[word] = true
+	}
+
+	phrases := make(map[string]*WordCount)
+	for _, tokens := range sentences {
+		for i := 0; i < len(tokens); {
+			if !top[tokens[i].BaseForm] {
+				i++
+				continue
+			}
+			j := i + 1
+			for j < len(tokens) && top[tokens[j].BaseForm] && tokens[j].Start == tokens[j-1].End {
+				j++
+			}
+
+			var text strings.Builder
+			score := 0.0
+			for _, token := range tokens[i:j] {
+				word := token.BaseForm
+				if j-i > 1 {
+					// 複数語のフレーズは本文中の表記でつなげる
+					word = token.Surface
+				}
+				text.WriteString(word)
+				score += rank[token.BaseForm]
+			}
+			addKeyword(phrases, text.String(), score)
+			i = j
+		}
+	}
+	return keywordList(phrases)
+}
+
+// rake は名詞の連続をフレーズの候補とし、単語の次数と出現回数の比の合計でスコアを付ける
+func (g *Generator) rake(messages []Message) []WordCount {
+	var candidates [][]Token
+	for _, msg := range messages {
+		var run []Token
+		flush := func() {
+			if len(run) > 0 {
+				candidates = append(candidates, run)
+				run = nil
+			}
+		}
+		for _, m := range g.analyzer.Morphemes(msg.Text) {
+			switch {
+			case strings.TrimSpace(m.Surface) == "":
+				// 英単語の間の空白はフレーズを区切らない
+				if len(run) == 0 {
+					continue
+				}
+			case m.POS == "名詞" && !g.analyzer.isStopWord(m.BaseForm) && !isNumeric(m.BaseForm):
+				run = append(run, g.config.Synonyms.foldSynonyms([]Token{m})...)
+			default:
+				flush()
+			}
+		}
+		flush()
+	}
+
+	// 単語ごとの出現回数と次数（同じ候補に含まれる単語の数の合計）
+	freq := make(map[string]float64)
+	degree := make(map[string]float64)
+	for _, run := range candidates {
+		for _, token := range run {
+			freq[token.BaseForm]++
+			degree[token.BaseForm] += float64(len(run))
+		}
+	}
+
+	phrases := make(map[string]*WordCount)
+	for _, run := range candidates {
+		var text strings.Builder
+		score := 0.0
+		for i, token := range run {
+			if i > 0 && token.Start != run[i-1].End {
+				text.WriteString(" ")
+			}
+			text.WriteString(token.Surface)
+			score += degree[token.BaseForm] / freq[token.BaseForm]
+		}
+		addKeyword(phrases, text.String(), score)
+	}
+
+	// 最小出現回数に満たないフレーズを除く
+	for text, phrase := range phrases {
+		if phrase.Count < g.config.MinCount {
+			delete(phrases, text)
+		}
+	}
+	return keywordList(phrases)
+}
+
+// addKeyword はフレーズの出現を集計する（同じフレーズのスコアは最大値を使う）
+func addKeyword(phrases map[string]*WordCount, text string, score float64) {
+	if phrase, ok := phrases[text]; ok {
+		phrase.Count++
+		phrase.Score = math.Max(phrase.Score, score)
+		return
+	}
+	phrases[text] = &WordCount{Text: text, Count: 1, Score: score}
+}
+
+// keywordList は集計したフレーズをスライスに変換
+func keywordList(phrases map[string]*WordCount) []WordCount {
+	list := make([]WordCount, 0, len(phrases))
+	for _, phrase := range phrases {
+		list = append(list, *phrase)
+	}
+	return list
+}
+
+// isNumeric は文字列が数字だけからなるかを判定
+func isNumeric(s string) bool {
+	for _, r := range s {
+		if !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// KeywordsForCSV はCSVファイルを読み込んでキーワードを抽出
+func (fp *FileProcessor) KeywordsForCSV(inputPath string, messageColumn int, opts KeywordOptions) ([]WordCount, error) {
+	messages, err := fp.ReadCSV(inputPath, messageColumn)
+	if err != nil {
+		return nil, err
+	}
+	return fp.generator.Keywords(messages, opts)
+}
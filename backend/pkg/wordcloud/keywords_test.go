@@ -0,0 +1,25 @@
+package wordcloud
+
+import "testing"
+
+func TestKeywordsNormalizesBeforeSplittingSentences(t *testing.T) {
+	config := defaultConfig()
+	config.MinCount = 1
+	generator, err := NewGenerator(config, newTestAnalyzer(t))
+	if err != nil {
+		t.Fatalf("ジェネレーターの初期化に失敗: %v", err)
+	}
+
+	for _, method := range []string{KeywordTextRank, KeywordRAKE} {
+		opts := DefaultKeywordOptions()
+		opts.Method = method
+		keywords, err := generator.Keywords([]Message{{Text: markupMessage}, {Text: markupMessage}}, opts)
+		if err != nil {
+			t.Fatalf("%s: Keywords() error = %v", method, err)
+		}
+		if len(keywords) == 0 {
+			t.Errorf("%s: キーワードがない", method)
+		}
+		checkNoMarkup(t, keywords)
+	}
+}
@@ -0,0 +1,21 @@
+package wordcloud
+
+import (
+	"io"
+	"log/slog"
+)
+
+// discardLogger はロガーを指定しない場合に使う、何も出力しないロガー
+var discardLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// GeneratorOption はGeneratorとFileProcessorの設定オプション関数の型
+type GeneratorOption func(*Generator)
+
+// WithLogger はログの出力先を設定するオプション（指定しない場合はログを出力しない）
+func WithLogger(logger *slog.Logger) GeneratorOption {
+	return func(g *Generator) {
+		if logger != nil {
+			g.logger = logger
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package wordcloud
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Message は解析対象のメッセージ
+type Message struct {
+	ID        string    // メッセージID（Slackのタイムスタンプ文字列）
+	Row       int       // 入力での行番号（CSVはヘッダーを1行目とする）
+	Text      string    // 本文
+	UserID    string    // 投稿者のユーザーID
+	Username  string    // 投稿者の表示名
+	Timestamp time.Time // 投稿日時
+	ThreadTS  string    // スレッドの親メッセージのタイムスタンプ
+}
+
+// ThreadKey はメッセージが属するスレッドを識別する値を返す
+// スレッドに属さないメッセージはメッセージ自身をスレッドとみなす
+func (m Message) ThreadKey() string {
+	if m.ThreadTS != "" {
+		return m.ThreadTS
+	}
+	return m.ID
+}
+
+// csvColumns はCSVの各列の位置（存在しない列は-1）
+type csvColumns struct {
+	text      int
+	timestamp int
+	userID    int
+	username  int
+	threadTS  int
+}
+
+// newCSVColumns はヘッダー行から列の位置を判定
+func newCSVColumns(header []string, messageColumn int) csvColumns {
+	columns := csvColumns{
+		text:      messageColumn,
+		timestamp: -1,
+		userID:    -1,
+		username:  -1,
+		threadTS:  -1,
+	}
+	for i, name := range header {
+		switch strings.TrimSpace(name) {
+		case "Timestamp":
+			columns.timestamp = i
+		case "UserID":
+			columns.userID = i
+		case "Username":
+			columns.username = i
+		case "ThreadTS":
+			columns.threadTS = i
+		}
+	}
+	return columns
+}
+
+// message はCSVのレコードからMessageを作成
+// メッセージ列が存在しないレコードの場合はfalseを返す
+func (c csvColumns) message(record []string, loc *time.Location) (Message, bool) {
+	if len(record) <= c.text {
+		return Message{}, false
+	}
+
+	field := func(i int) string {
+		if i < 0 || i >= len(record) {
+			return ""
+		}
+		return record[i]
+	}
+
+	ts := field(c.timestamp)
+	return Message{
+		ID:        ts,
+		Text:      record[c.text],
+		UserID:    field(c.userID),
+		Username:  field(c.username),
+		Timestamp: ParseTimestamp(ts, loc),
+		ThreadTS:  field(c.threadTS),
+	}, true
+}
+
+// timestampLayouts はSlackのタイムスタンプ以外に受け付ける日時の形式
+var timestampLayouts = []string{
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	"2006/01/02 15:04:05",
+	"2006-01-02",
+}
+
+// ParseTimestamp はSlackのタイムスタンプ（"1706580000.123456"）または日時文字列を解析
+// タイムゾーンを含まない日時はlocの時刻として扱う。解析できない場合はゼロ値を返す
+func ParseTimestamp(value string, loc *time.Location) time.Time {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}
+	}
+
+	if sec, frac, ok := strings.Cut(value, "."); ok || isDigits(sec) {
+		if s, err := strconv.ParseInt(sec, 10, 64); err == nil && isDigits(frac) {
+			nsec := int64(0)
+			if frac != "" {
+				frac = (frac + "000000000")[:9]
+				nsec, _ = strconv.ParseInt(frac, 10, 64)
+			}
+			return time.Unix(s, nsec).In(loc)
+		}
+	}
+
+	for _, layout := range timestampLayouts {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// isDigits は文字列が数字のみで構成されているかを判定（空文字列はtrue）
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
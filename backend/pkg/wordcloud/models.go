@@ -1,18 +1,35 @@
 package wordcloud
 
+import "time"
+
 // Token は形態素解析結果のトークン
 type Token struct {
 	Surface  string `json:"surface"`   // 表層形
 	BaseForm string `json:"base_form"` // 基本形
 	POS      string `json:"pos"`       // 品詞
+	Start    int    `json:"-"`         // テキスト中の開始位置（文字単位）
+	End      int    `json:"-"`         // テキスト中の終了位置（文字単位）
 }
 
 // WordCount は単語の出現回数情報
 type WordCount struct {
-	Text     string `json:"text"`     // 単語
-	Count    int    `json:"count"`    // 出現回数
-	FontSize int    `json:"fontSize"` // フォントサイズ
-	Color    string `json:"color"`    // 色
+	Text      string         `json:"text"`                // 単語
+	Count     int            `json:"count"`               // 出現回数
+	FontSize  int            `json:"fontSize"`            // フォントサイズ
+	Color     string         `json:"color"`               // 色
+	Score     float64        `json:"score,omitempty"`     // 重み付けを行った場合のスコア
+	Variants  map[string]int `json:"variants,omitempty"`  // 同義語として統合された表記ごとの出現回数
+	Sentiment float64        `json:"sentiment,omitempty"` // 単語が出現したメッセージの平均感情スコア（-1〜1）
+	POS       string         `json:"pos,omitempty"`       // 最も多く出現した品詞（フレーズはPhrasePOS）
+	User      string         `json:"user,omitempty"`      // 最も多く使ったユーザーのID
+}
+
+// value は重み付けの方式に応じてサイズや色を決める値を返す
+func (wc WordCount) value(weighting string) float64 {
+	if weighting == "" || weighting == WeightingCount {
+		return float64(wc.Count)
+	}
+	return wc.Score
 }
 
 // Config はワードクラウドの設定
@@ -21,9 +38,45 @@ type Config struct {
 	MaxWords    int    // 最大単語数
 	MinFontSize int    // 最小フォントサイズ
 	MaxFontSize int    // 最大フォントサイズ
-	ColorScheme string // 色スキーム
+	ColorScheme string // 色スキーム（NewColorSchemeを参照）
 	Width       int    // 画像の幅
 	Height      int    // 画像の高さ
+	FontPath    string // 描画に使うTrueTypeフォントのパス（空の場合は既定のフォント）
+
+	FontScale       string  // フォントサイズの割り当て方式（linear/log/sqrt/rank/relative、空の場合は出現回数ならlog、それ以外はlinear）
+	RelativeScaling float64 // relativeで直前の単語との値の比を反映する度合い（0〜1、0の場合は0.5）
+	FitCanvas       bool    // 描画時に単語が画像を埋めるようにフォントサイズを一律に拡大・縮小する
+
+	Colors      ColorScheme // 独自の色スキーム（設定した場合はColorSchemeより優先）
+	Palette     Palette     // 色スキームで使うパレット（空の場合は色スキームごとの既定）
+	Background  string      // 背景色（空の場合は白）
+	MinContrast float64     // 背景色との最小コントラスト比（0の場合は3.0、負の値で確認しない）
+
+	Synonyms Synonyms // 同義語の対応表（集計前に別名を正規形へ統合）
+
+	NGramSize  int // フレーズとして集計する最大の単語数（2以上で有効）
+	MaxPhrases int // 出力に含めるフレーズの最大数（0以下は無制限）
+
+	Weighting    string           // 単語の重み付け方式（count/tfidf/loglikelihood）
+	DocumentUnit string           // TF-IDFで文書とみなす単位（message/thread/day）
+	Reference    *ReferenceCorpus // 対数尤度比で比較する参照コーパス
+	TimeLocation *time.Location   // 日付の判定に使うタイムゾーン（nilの場合はAsia/Tokyo）
+
+	ExcludeUsers []string // 集計から除外するユーザーIDまたはユーザー名
+	Filter       string   // CSVの読み込み時にメッセージを選択するフィルター式（ParseFilterを参照）
+
+	Sentiment SentimentLexicon // メッセージごとの感情スコアに使う辞書（設定した場合は単語に平均感情スコアを付与）
+}
+
+// location は日付の判定に使うタイムゾーンを返す
+func (c Config) location() *time.Location {
+	if c.TimeLocation != nil {
+		return c.TimeLocation
+	}
+	if jst, err := time.LoadLocation("Asia/Tokyo"); err == nil {
+		return jst
+	}
+	return time.Local
 }
 
 // defaultConfig はデフォルト設定を返す
@@ -36,6 +89,8 @@ func defaultConfig() Config {
 		ColorScheme: "blue",
 		Width:       800,
 		Height:      600,
+		MaxPhrases:  20,
+		Weighting:   WeightingCount,
 	}
 }
 
@@ -51,6 +106,14 @@ func WithStopWords(words []string) Option {
 	}
 }
 
+// WithNormalizers は形態素解析前に適用する正規化処理を設定するオプション
+// 何も指定しない場合は正規化を行わない
+func WithNormalizers(normalizers ...Normalizer) Option {
+	return func(a *Analyzer) {
+		a.normalizer = NormalizerChain(normalizers)
+	}
+}
+
 // targetPOS は対象とする品詞
 var targetPOS = map[string]bool{
 	"名詞":  true,
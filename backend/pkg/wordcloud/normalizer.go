@@ -0,0 +1,166 @@
+package wordcloud
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Normalizer は形態素解析の前にテキストを正規化するインターフェース
+type Normalizer interface {
+	Normalize(text string) string
+}
+
+// NormalizerFunc は関数をNormalizerとして扱うためのアダプタ
+type NormalizerFunc func(text string) string
+
+// Normalize はNormalizerインターフェースの実装
+func (f NormalizerFunc) Normalize(text string) string {
+	return f(text)
+}
+
+// NormalizerChain は複数のNormalizerを順番に適用する
+type NormalizerChain []Normalizer
+
+// Normalize はチェーン内のNormalizerを先頭から順に適用
+func (c NormalizerChain) Normalize(text string) string {
+	for _, n := range c {
+		text = n.Normalize(text)
+	}
+	return text
+}
+
+// 正規化処理の名前
+const (
+	NormalizeNFKC      = "nfkc"    // Unicode NFKC正規化（半角カナ・全角英数字）
+	NormalizeCodeBlock = "code"    // コードブロック・インラインコードの除去
+	NormalizeSlack     = "slack"   // Slackのmrkdwn記法の除去
+	NormalizeVariant   = "variant" // 表記ゆれ（長音など）の統一
+)
+
+// DefaultNormalizerNames はコマンドの既定で適用する正規化処理の名前
+// コードブロックの除去はSlack記法の除去より先に行う必要がある
+var DefaultNormalizerNames = []string{
+	NormalizeNFKC,
+	NormalizeCodeBlock,
+	NormalizeSlack,
+	NormalizeVariant,
+}
+
+// DefaultNormalizers はコマンドの既定の正規化チェーンを返す
+func DefaultNormalizers() NormalizerChain {
+	chain, _ := NewNormalizerChain(DefaultNormalizerNames...)
+	return chain
+}
+
+// NewNormalizerChain は名前から正規化チェーンを作成
+func NewNormalizerChain(names ...string) (NormalizerChain, error) {
+	var chain NormalizerChain
+	for _, name := range names {
+		name = strings.TrimSpace(strings.ToLower(name))
+		if name == "" {
+			continue
+		}
+		n, ok := normalizers[name]
+		if !ok {
+			return nil, fmt.Errorf("不明な正規化処理です: %s", name)
+		}
+		chain = append(chain, n)
+	}
+	return chain, nil
+}
+
+// ParseNormalizers はカンマ区切りの名前から正規化チェーンを作成
+// "none" を指定すると正規化を行わない
+func ParseNormalizers(spec string) (NormalizerChain, error) {
+	if strings.TrimSpace(spec) == "none" {
+		return NormalizerChain{}, nil
+	}
+	return NewNormalizerChain(strings.Split(spec, ",")...)
+}
+
+// normalizers は名前で選択できる正規化処理
+var normalizers = map[string]Normalizer{
+	NormalizeNFKC:      NormalizerFunc(normalizeNFKC),
+	NormalizeCodeBlock: NormalizerFunc(removeCodeBlocks),
+	NormalizeSlack:     NormalizerFunc(stripSlackMarkup),
+	NormalizeVariant:   NormalizerFunc(foldVariants),
+}
+
+// normalizeNFKC は半角カナや全角英数字をNFKCで正規化
+func normalizeNFKC(text string) string {
+	return norm.NFKC.String(text)
+}
+
+var (
+	codeBlockPattern  = regexp.MustCompile("(?s)```.*?```")
+	inlineCodePattern = regexp.MustCompile("`[^`\n]+`")
+)
+
+// removeCodeBlocks はコードブロックとインラインコードを除去
+func removeCodeBlocks(text string) string {
+	text = codeBlockPattern.ReplaceAllString(text, " ")
+	return inlineCodePattern.ReplaceAllString(text, " ")
+}
+
+var (
+	// <#C123|general> や <https://example.com|ラベル> などの山括弧表記
+	slackLinkPattern = regexp.MustCompile(`<([^<>|]*)(?:\|([^<>]*))?>`)
+	// *太字* _斜体_ ~取り消し線~
+	slackEmphasisPattern = regexp.MustCompile(`(^|[\s(])([*_~])([^*_~\n]+)([*_~])`)
+	// 引用記号
+	slackQuotePattern = regexp.MustCompile(`(?m)^\s*(&gt;|>)+\s?`)
+	// HTMLエスケープ
+	slackEscapeReplacer = strings.NewReplacer("&amp;", "&", "&lt;", "<", "&gt;", ">")
+)
+
+// stripSlackMarkup はSlackのmrkdwn記法を取り除き、表示テキストだけを残す
+// ユーザーメンション（<@U123>）は匿名化のためAnalyzerで処理するので残す
+func stripSlackMarkup(text string) string {
+	text = slackQuotePattern.ReplaceAllString(text, "")
+	text = slackLinkPattern.ReplaceAllStringFunc(text, func(m string) string {
+		sub := slackLinkPattern.FindStringSubmatch(m)
+		target, label := sub[1], sub[2]
+		switch {
+		case strings.HasPrefix(target, "@"):
+			return m
+		case strings.HasPrefix(target, "#"), strings.HasPrefix(target, "!subteam"):
+			// チャンネル・ユーザーグループはラベルがあれば名前として残す
+			return " " + label + " "
+		case strings.HasPrefix(target, "!"):
+			// <!here> <!channel> などの特殊メンション
+			return " "
+		default:
+			// リンクはラベルのみ残し、URL自体は除外
+			return " " + label + " "
+		}
+	})
+	text = slackEmphasisPattern.ReplaceAllStringFunc(text, func(m string) string {
+		sub := slackEmphasisPattern.FindStringSubmatch(m)
+		if sub[2] != sub[4] {
+			return m
+		}
+		return sub[1] + sub[3]
+	})
+	return slackEscapeReplacer.Replace(text)
+}
+
+// katakanaPattern はカタカナの連続を表す
+var katakanaPattern = regexp.MustCompile(`[ァ-ヺー]+`)
+
+// minVariantLength は末尾の長音を取り除くカタカナ語の最小文字数
+const minVariantLength = 4
+
+// foldVariants はカタカナ語末尾の長音を取り除いて表記を統一する
+// 例: "サーバー" と "サーバ" を "サーバ" に揃える
+func foldVariants(text string) string {
+	return katakanaPattern.ReplaceAllStringFunc(text, func(word string) string {
+		runes := []rune(word)
+		if len(runes) >= minVariantLength && runes[len(runes)-1] == 'ー' {
+			return string(runes[:len(runes)-1])
+		}
+		return word
+	})
+}
@@ -0,0 +1,114 @@
+package wordcloud
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// sentenceBoundary は文の区切りとみなす文字
+var sentenceBoundary = regexp.MustCompile(`[。．！？!?\n]+`)
+
+// splitSentences はテキストを文単位に分割
+func splitSentences(text string) []string {
+	var sentences []string
+	for _, s := range sentenceBoundary.Split(text, -1) {
+		if strings.TrimSpace(s) != "" {
+			sentences = append(sentences, s)
+		}
+	}
+	return sentences
+}
+
+// PhrasePOS はフレーズとして集計した単語の品詞の代わりに使う値
+const PhrasePOS = "フレーズ"
+
+// phraseSeparator はフレーズを構成する単語の区切り文字
+const phraseSeparator = " "
+
+// phraseCounter は文ごとのトークン列からN-gramを集計する
+type phraseCounter struct {
+	maxN     int
+	unigrams map[string]int
+	ngrams   map[string]int
+	total    int
+}
+
+// newPhraseCounter は2からmaxNまでのN-gramを集計するphraseCounterを作成
+func newPhraseCounter(maxN int) *phraseCounter {
+	return &phraseCounter{
+		maxN:     maxN,
+		unigrams: make(map[string]int),
+		ngrams:   make(map[string]int),
+	}
+}
+
+// add は1文分のトークン列を集計に加え、出現したN-gramを返す
+func (pc *phraseCounter) add(tokens []Token) []string {
+	var phrases []string
+	for i, token := range tokens {
+		pc.unigrams[token.BaseForm]++
+		pc.total++
+
+		for n := 2; n <= pc.maxN && i+n <= len(tokens); n++ {
+			words := make([]string, n)
+			for k := range words {
+				words[k] = tokens[i+k].BaseForm
+			}
+			phrase := strings.Join(words, phraseSeparator)
+			pc.ngrams[phrase]++
+			phrases = append(phrases, phrase)
+		}
+	}
+	return phrases
+}
+
+// pmi はN-gramの自己相互情報量を計算
+// PMI = log(P(w1..wn) / (P(w1)...P(wn)))
+func (pc *phraseCounter) pmi(phrase string, count int) float64 {
+	total := float64(pc.total)
+	score := math.Log(float64(count) / total)
+	for _, word := range strings.Split(phrase, phraseSeparator) {
+		score -= math.Log(float64(pc.unigrams[word]) / total)
+	}
+	return score
+}
+
+// top は出現回数がminCount以上のフレーズをPMIの高い順に最大limit件（0以下は無制限）返す
+func (pc *phraseCounter) top(minCount, limit int) []WordCount {
+	type scored struct {
+		phrase string
+		count  int
+		score  float64
+	}
+
+	var candidates []scored
+	for phrase, count := range pc.ngrams {
+		if count < minCount {
+			continue
+		}
+		score := pc.pmi(phrase, count)
+		if score <= 0 {
+			// 偶然の共起と区別できないものは除外
+			continue
+		}
+		candidates = append(candidates, scored{phrase, count, score})
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].score != candidates[j].score {
+			return candidates[i].score > candidates[j].score
+		}
+		return candidates[i].phrase < candidates[j].phrase
+	})
+	if limit > 0 && len(candidates) > limit {
+		candidates = candidates[:limit]
+	}
+
+	phrases := make([]WordCount, len(candidates))
+	for i, c := range candidates {
+		phrases[i] = WordCount{Text: c.phrase, Count: c.count, POS: PhrasePOS}
+	}
+	return phrases
+}
@@ -0,0 +1,81 @@
+package wordcloud
+
+// 進捗を通知する処理の段階
+const (
+	StageReadCSV = "read_csv" // CSVファイルの読み込み（行数）
+	StageAnalyze = "analyze"  // メッセージの形態素解析（メッセージ数）
+	StageTopics  = "topics"   // トピックの推定（反復回数）
+)
+
+// Progress は時間のかかる処理の進捗を受け取るインターフェース
+type Progress interface {
+	// Report は段階stageの処理がtotal件中done件まで進んだことを通知する（totalが0の場合は総数が不明）
+	Report(stage string, done, total int)
+}
+
+// ProgressFunc は関数をProgressとして使うための型
+type ProgressFunc func(stage string, done, total int)
+
+// Report はfを呼び出す
+func (f ProgressFunc) Report(stage string, done, total int) {
+	f(stage, done, total)
+}
+
+// progressReporter は進捗の割合が1%以上進んだとき、または処理が終わったときだけ通知する
+type progressReporter struct {
+	progress Progress
+	stage    string
+	total    int
+	percent  int
+}
+
+// newProgressReporter は段階stageの進捗を通知するprogressReporterを返す（progressがnilの場合は何もしない）
+func newProgressReporter(progress Progress, stage string, total int) *progressReporter {
+	r := &progressReporter{progress: progress, stage: stage, total: total, percent: -1}
+	r.report(0)
+	return r
+}
+
+// report はdone件まで進んだことを通知する
+func (r *progressReporter) report(done int) {
+	if r.progress == nil {
+		return
+	}
+	percent := done * 100 / max(r.total, 1)
+	if percent <= r.percent && done != r.total {
+		return
+	}
+	r.percent = percent
+	r.progress.Report(r.stage, done, r.total)
+}
+
+// finish は見積もった総数と異なる場合も含め、done件で処理が終わったことを通知する
+func (r *progressReporter) finish(done int) {
+	r.total = done
+	r.report(done)
+}
+
+// GenerateOption はCSVファイルの読み込みやワードクラウドデータの生成1回ごとのオプション関数の型
+type GenerateOption func(*generateOptions)
+
+// generateOptions は1回の読み込み・生成のオプション
+type generateOptions struct {
+	progress Progress // 進捗の通知先（nilの場合は通知しない）
+}
+
+// WithProgress は処理の進捗をprogressに通知するオプション
+// 呼び出しごとに指定するため、同じGeneratorを並行して使う場合もそれぞれの通知先に届く
+func WithProgress(progress Progress) GenerateOption {
+	return func(opts *generateOptions) {
+		opts.progress = progress
+	}
+}
+
+// newGenerateOptions はオプションを適用したgenerateOptionsを返す
+func newGenerateOptions(options []GenerateOption) generateOptions {
+	var opts generateOptions
+	for _, opt := range options {
+		opt(&opts)
+	}
+	return opts
+}
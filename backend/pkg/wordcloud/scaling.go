@@ -0,0 +1,161 @@
+package wordcloud
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/fogleman/gg"
+	"github.com/golang/freetype/truetype"
+)
+
+// フォントサイズの割り当て方式
+const (
+	FontScaleLinear   = "linear"   // 値に比例
+	FontScaleLog      = "log"      // 値の対数に比例
+	FontScaleSqrt     = "sqrt"     // 値の平方根に比例（文字の面積が値に比例）
+	FontScaleRank     = "rank"     // 順位に応じて等間隔
+	FontScaleRelative = "relative" // 直前の単語との値の比をRelativeScalingの度合いで反映
+)
+
+// defaultRelativeScaling はRelativeScalingが未指定の場合に使う度合い
+const defaultRelativeScaling = 0.5
+
+// fontScale は出現回数による集計に使うフォントサイズの割り当て方式を返す
+// 未指定の場合は出現回数なら対数、それ以外の重み付けなら線形に割り当てる
+func (c Config) fontScale() string {
+	if c.FontScale != "" {
+		return c.FontScale
+	}
+	if c.Weighting == "" || c.Weighting == WeightingCount {
+		return FontScaleLog
+	}
+	return FontScaleLinear
+}
+
+// relativeScaling はrelativeで値の比を反映する度合い（0〜1）を返す
+func (c Config) relativeScaling() float64 {
+	if c.RelativeScaling <= 0 {
+		return defaultRelativeScaling
+	}
+	return math.Min(c.RelativeScaling, 1)
+}
+
+// scaleFontSizes は値の大きい順に並んだ単語にフォントサイズを割り当てる
+// 値がすべて等しい場合や最大値が0以下の場合はすべて最大フォントサイズになる
+func (g *Generator) scaleFontSizes(words []WordCount, scale string, value func(WordCount) float64) error {
+	if len(words) == 0 {
+		return nil
+	}
+
+	maxValue := value(words[0])
+	for _, word := range words {
+		maxValue = math.Max(maxValue, value(word))
+	}
+	ratioOf := func(v float64) float64 {
+		if maxValue <= 0 {
+			return 1
+		}
+		return v / maxValue
+	}
+
+	switch scale {
+	case FontScaleLinear:
+		for i := range words {
+			words[i].FontSize = g.linearFontSize(ratioOf(value(words[i])))
+		}
+	case FontScaleLog:
+		for i := range words {
+			ratio := 1.0
+			if maxValue > 0 {
+				ratio = math.Log1p(math.Max(value(words[i]), 0)) / math.Log1p(maxValue)
+			}
+			words[i].FontSize = g.linearFontSize(ratio)
+		}
+	case FontScaleSqrt:
+		for i := range words {
+			words[i].FontSize = g.linearFontSize(math.Sqrt(math.Max(ratioOf(value(words[i])), 0)))
+		}
+	case FontScaleRank:
+		for i := range words {
+			ratio := 1.0
+			if len(words) > 1 {
+				ratio = 1 - float64(i)/float64(len(words)-1)
+			}
+			words[i].FontSize = g.linearFontSize(ratio)
+		}
+	case FontScaleRelative:
+		// 先頭を最大フォントサイズとし、以降は直前の単語のサイズに値の比を掛ける
+		rs := g.config.relativeScaling()
+		size := float64(g.config.MaxFontSize)
+		for i := range words {
+			if i > 0 {
+				if prev := value(words[i-1]); prev > 0 {
+					size *= rs*math.Max(value(words[i]), 0)/prev + (1 - rs)
+				}
+			}
+			size = math.Max(size, float64(g.config.MinFontSize))
+			words[i].FontSize = int(math.Round(size))
+		}
+	default:
+		return fmt.Errorf("不明なフォントサイズの割り当て方式です: %s", scale)
+	}
+	return nil
+}
+
+// 配置の調整に使うパラメータ
+const (
+	minPlaceFontSize = 6    // 配置できない単語を縮小する下限のフォントサイズ
+	shrinkFactor     = 0.85 // 配置できない単語を縮小する倍率
+	fitFillRatio     = 0.5  // 自動調整で単語の外接矩形が占める面積の目標（描画領域に対する比率）
+	fitAttempts      = 8    // 自動調整で縮小しながら配置を試す最大回数
+	fitShrinkFactor  = 0.9  // 自動調整で配置しきれなかった場合の縮小率
+)
+
+// fitWords は単語が描画領域を埋めるようにフォントサイズを一律に拡大・縮小して配置する
+// 外接矩形の面積の合計から倍率を見積もり、縮小せずに配置できない単語があれば倍率を下げて再試行する
+func fitWords(dc *gg.Context, font *truetype.Font, data []WordCount, region Rectangle) []placedWord {
+	if len(data) == 0 {
+		return nil
+	}
+
+	area := 0.0
+	for _, word := range data {
+		dc.SetFontFace(newFace(font, float64(word.FontSize)))
+		w, h := dc.MeasureString(word.Text)
+		area += (w + 4) * (h + 4)
+	}
+	if area == 0 {
+		return placeWords(dc, font, data, region)
+	}
+	factor := math.Sqrt(fitFillRatio * region.W * region.H / area)
+
+	var placed []placedWord
+	scaled := make([]WordCount, len(data))
+	for attempt := 0; attempt < fitAttempts; attempt++ {
+		copy(scaled, data)
+		for i := range scaled {
+			scaled[i].FontSize = max(int(float64(data[i].FontSize)*factor), minPlaceFontSize)
+		}
+
+		placed = placeWords(dc, font, scaled, region)
+		if len(placed) == len(scaled) && !shrunk(placed, scaled) {
+			break
+		}
+		factor *= fitShrinkFactor
+	}
+	return placed
+}
+
+// shrunk は配置のために縮小された単語があるかを判定
+func shrunk(placed []placedWord, data []WordCount) bool {
+	sizes := make(map[string]int, len(data))
+	for _, word := range data {
+		sizes[word.Text] = word.FontSize
+	}
+	for _, p := range placed {
+		if p.FontSize < sizes[p.Text] {
+			return true
+		}
+	}
+	return false
+}
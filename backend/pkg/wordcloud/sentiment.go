@@ -0,0 +1,177 @@
+package wordcloud
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ColorSentiment は単語の平均感情スコアで赤・灰・緑に色分けする色スキーム
+const ColorSentiment = "sentiment"
+
+// SentimentLexicon は単語（基本形）から極性（-1〜1）への対応表
+// 複数の形態素からなる見出しは基本形を半角スペースで区切って登録する
+type SentimentLexicon map[string]float64
+
+// maxSentimentTokens は感情辞書の見出しとして照合する最大の形態素数
+const maxSentimentTokens = 3
+
+// sentimentNegations は直前の評価表現の極性を反転させる否定の基本形
+var sentimentNegations = map[string]bool{
+	"ない": true, "ぬ": true, "ん": true, "ず": true,
+}
+
+// ParseSentimentLexicon はテキストから感情辞書を読み込む
+// 1行に "単語<TAB>極性" を記述し、区切りにはカンマも使用できる
+// 極性は -1〜1 の数値か、p/n/e・ポジ/ネガ で始まるラベルで指定する
+// 日本語評価極性辞書のように極性が先頭の列にある形式も読み込める
+func ParseSentimentLexicon(r io.Reader) (SentimentLexicon, error) {
+	lexicon := make(SentimentLexicon)
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.FieldsFunc(line, func(r rune) bool { return r == '\t' || r == ',' })
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("%d行目の形式が不正です: %q", lineNo, line)
+		}
+		word, label := strings.TrimSpace(fields[0]), strings.TrimSpace(fields[1])
+		polarity, ok := parsePolarity(label)
+		if !ok {
+			// 極性が先頭の列にある形式
+			if polarity, ok = parsePolarity(word); !ok {
+				return nil, fmt.Errorf("%d行目の極性を解析できません: %q", lineNo, line)
+			}
+			word = label
+		}
+		if word = strings.Join(strings.Fields(word), " "); word != "" {
+			lexicon[word] = polarity
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("感情辞書の読み込みに失敗: %w", err)
+	}
+	return lexicon, nil
+}
+
+// parsePolarity は数値またはラベルの極性を -1〜1 の値に変換
+func parsePolarity(s string) (float64, bool) {
+	if v, err := strconv.ParseFloat(s, 64); err == nil {
+		return math.Max(-1, math.Min(1, v)), true
+	}
+	switch {
+	case s == "p", strings.HasPrefix(s, "ポジ"):
+		return 1, true
+	case s == "n", strings.HasPrefix(s, "ネガ"):
+		return -1, true
+	case s == "e":
+		return 0, true
+	}
+	return 0, false
+}
+
+// LoadSentimentLexicon はファイルから感情辞書を読み込む
+func LoadSentimentLexicon(path string) (SentimentLexicon, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("感情辞書のオープンに失敗: %w", err)
+	}
+	defer file.Close()
+
+	lexicon, err := ParseSentimentLexicon(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return lexicon, nil
+}
+
+// DefaultSentimentLexicon は組み込みの小さな感情辞書を返す
+func DefaultSentimentLexicon() SentimentLexicon {
+	return SentimentLexicon{
+		// ポジティブ
+		"良い": 1, "よい": 1, "いい": 1, "嬉しい": 1, "うれしい": 1, "楽しい": 1,
+		"助かる": 1, "ありがとう": 1, "感謝": 1, "素晴らしい": 1, "最高": 1,
+		"便利": 0.8, "成功": 0.8, "解決": 0.8, "改善": 0.6, "安定": 0.6,
+		"快適": 0.8, "順調": 0.8, "完了": 0.4, "おめでとう": 1, "好き": 0.8,
+		"速い": 0.5, "早い": 0.4, "簡単": 0.5, "安心": 0.8, "喜ぶ": 1,
+		":+1:": 0.8, ":tada:": 1, ":pray:": 0.6, ":smile:": 0.8,
+		// ネガティブ
+		"悪い": -1, "辛い": -1, "つらい": -1, "困る": -1, "難しい": -0.5,
+		"失敗": -0.8, "障害": -0.8, "エラー": -0.6, "バグ": -0.6, "問題": -0.5,
+		"遅い": -0.5, "不具合": -0.8, "残念": -0.8, "最悪": -1, "嫌い": -0.8,
+		"不安": -0.8, "面倒": -0.6, "大変": -0.6, "疲れる": -0.6, "怖い": -0.8,
+		"落ちる": -0.6, "壊れる": -0.8, "炎上": -1, "遅延": -0.6, "申し訳": -0.4,
+		":sob:": -0.8, ":cry:": -0.8, ":rage:": -1, ":scream:": -0.8,
+	}
+}
+
+// Score は形態素列の感情スコア（-1〜1）を返す
+// 一致した評価表現の極性の平均で、直後に否定が続く表現は極性を反転する
+// 評価表現が含まれない場合は0を返す
+func (l SentimentLexicon) Score(morphemes []Token) float64 {
+	sum, matched := 0.0, 0
+	for i := 0; i < len(morphemes); {
+		polarity, n := l.match(morphemes[i:])
+		if n == 0 {
+			i++
+			continue
+		}
+		i += n
+		if i < len(morphemes) && sentimentNegations[morphemes[i].BaseForm] {
+			polarity = -polarity
+			i++
+		}
+		sum += polarity
+		matched++
+	}
+	if matched == 0 {
+		return 0
+	}
+	return sum / float64(matched)
+}
+
+// match は先頭から最長一致する見出しの極性と形態素数を返す（一致しない場合は0）
+func (l SentimentLexicon) match(morphemes []Token) (float64, int) {
+	for n := min(maxSentimentTokens, len(morphemes)); n >= 1; n-- {
+		words := make([]string, n)
+		for i := range words {
+			words[i] = morphemes[i].BaseForm
+		}
+		if polarity, ok := l[strings.Join(words, " ")]; ok {
+			return polarity, n
+		}
+		if n == 1 {
+			if polarity, ok := l[morphemes[0].Surface]; ok {
+				return polarity, 1
+			}
+		}
+	}
+	return 0, 0
+}
+
+// sentimentLexicon は集計に使う感情辞書を返す
+// 色スキームがsentimentで辞書が指定されていない場合は組み込みの辞書を使う
+func (c Config) sentimentLexicon() SentimentLexicon {
+	if c.Sentiment == nil && c.ColorScheme == ColorSentiment {
+		return DefaultSentimentLexicon()
+	}
+	return c.Sentiment
+}
+
+// Sentiment はテキストの感情スコア（-1〜1）を返す
+func (g *Generator) Sentiment(text string) float64 {
+	lexicon := g.config.sentimentLexicon()
+	if lexicon == nil {
+		lexicon = DefaultSentimentLexicon()
+	}
+	return lexicon.Score(g.analyzer.Morphemes(text))
+}
@@ -0,0 +1,216 @@
+package wordcloud
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// StopWords はストップワードの集合と除外規則
+type StopWords struct {
+	Words    []string         // 完全一致で除外する単語
+	Prefixes []string         // 前方一致で除外する接頭辞
+	Patterns []*regexp.Regexp // 正規表現で除外するパターン
+}
+
+// Merge は別のストップワードを追加する
+func (s *StopWords) Merge(other *StopWords) {
+	if other == nil {
+		return
+	}
+	s.Words = append(s.Words, other.Words...)
+	s.Prefixes = append(s.Prefixes, other.Prefixes...)
+	s.Patterns = append(s.Patterns, other.Patterns...)
+}
+
+// ストップワードファイルの行頭に付ける規則の種類
+const (
+	stopWordRegexPrefix  = "re:"
+	stopWordPrefixPrefix = "prefix:"
+)
+
+// ParseStopWords はテキストからストップワードを読み込む
+// 1行に1単語を記述し、"#" で始まる行はコメントとして扱う
+// "re:" で始まる行は正規表現、"prefix:" で始まる行は接頭辞として扱う
+func ParseStopWords(r io.Reader) (*StopWords, error) {
+	s := &StopWords{}
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(line, stopWordRegexPrefix):
+			re, err := regexp.Compile(strings.TrimPrefix(line, stopWordRegexPrefix))
+			if err != nil {
+				return nil, fmt.Errorf("%d行目の正規表現が不正です: %w", lineNo, err)
+			}
+			s.Patterns = append(s.Patterns, re)
+		case strings.HasPrefix(line, stopWordPrefixPrefix):
+			if prefix := strings.TrimPrefix(line, stopWordPrefixPrefix); prefix != "" {
+				s.Prefixes = append(s.Prefixes, prefix)
+			}
+		default:
+			s.Words = append(s.Words, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("ストップワードの読み込みに失敗: %w", err)
+	}
+	return s, nil
+}
+
+// LoadStopWordFile はファイルからストップワードを読み込む
+func LoadStopWordFile(path string) (*StopWords, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("ストップワードファイルのオープンに失敗: %w", err)
+	}
+	defer file.Close()
+
+	s, err := ParseStopWords(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return s, nil
+}
+
+// presetSpecPrefix は組み込みプリセットを指定する際の接頭辞
+const presetSpecPrefix = "preset:"
+
+// LoadStopWords は複数の指定からストップワードを読み込んで結合する
+// "preset:ja" のように指定すると組み込みプリセットを、それ以外はファイルパスとして読み込む
+func LoadStopWords(specs ...string) (*StopWords, error) {
+	merged := &StopWords{}
+	for _, spec := range specs {
+		spec = strings.TrimSpace(spec)
+		if spec == "" {
+			continue
+		}
+
+		var (
+			s   *StopWords
+			err error
+		)
+		if name, ok := strings.CutPrefix(spec, presetSpecPrefix); ok {
+			s, err = StopWordPreset(name)
+		} else {
+			s, err = LoadStopWordFile(spec)
+		}
+		if err != nil {
+			return nil, err
+		}
+		merged.Merge(s)
+	}
+	return merged, nil
+}
+
+// StopWordPreset は組み込みのストップワードプリセットを返す
+func StopWordPreset(name string) (*StopWords, error) {
+	preset, ok := stopWordPresets[name]
+	if !ok {
+		return nil, fmt.Errorf("不明なストップワードプリセットです: %s", name)
+	}
+	return preset(), nil
+}
+
+// StopWordPresetNames は利用可能なプリセット名を返す
+func StopWordPresetNames() []string {
+	names := make([]string, 0, len(stopWordPresets))
+	for name := range stopWordPresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// WithStopWordRules はストップワードの集合と除外規則を追加するオプション
+func WithStopWordRules(s *StopWords) Option {
+	return func(a *Analyzer) {
+		if s == nil {
+			return
+		}
+		for _, word := range s.Words {
+			a.stopWords[word] = true
+		}
+		a.stopPrefixes = append(a.stopPrefixes, s.Prefixes...)
+		a.stopPatterns = append(a.stopPatterns, s.Patterns...)
+	}
+}
+
+// stopWordPresets は組み込みのストップワードプリセット
+var stopWordPresets = map[string]func() *StopWords{
+	"ja":    japaneseStopWords,
+	"en":    englishStopWords,
+	"slack": slackStopWords,
+}
+
+// japaneseStopWords はSlothLibのリストを参考にした日本語のストップワード
+func japaneseStopWords() *StopWords {
+	return &StopWords{
+		Words: []string{
+			"あそこ", "あっ", "あの", "あのかた", "あの人", "あり", "あります", "ある", "あれ",
+			"い", "いう", "います", "いる", "う", "うち", "え", "お", "および", "おり", "おります",
+			"か", "かつて", "から", "が", "き", "ここ", "こちら", "こと", "この", "これ", "これら",
+			"さ", "さらに", "し", "しかし", "する", "ず", "せ", "せる", "そこ", "そして", "その",
+			"その他", "その後", "それ", "それぞれ", "それで", "た", "ただし", "たち", "ため", "たり",
+			"だ", "だっ", "だれ", "つ", "て", "で", "でき", "できる", "です", "では", "でも", "と",
+			"という", "といった", "とき", "ところ", "として", "とともに", "とも", "と共に", "どこ",
+			"どの", "な", "ない", "なお", "なかっ", "ながら", "なく", "なっ", "など", "なに", "なら",
+			"なり", "なる", "なん", "に", "において", "における", "について", "にて", "によって",
+			"により", "による", "に対して", "に対する", "に関する", "の", "ので", "のみ", "は", "ば",
+			"へ", "ほか", "ほとんど", "ほど", "ます", "また", "または", "まで", "も", "もの",
+			"ものの", "や", "よう", "より", "ら", "られ", "られる", "れ", "れる", "を", "ん",
+			"何", "及び", "彼", "彼女", "我々", "特に", "私", "私達", "貴方", "貴方方",
+			"思う", "言う", "よる", "おく", "みる", "しまう", "さん", "くん", "ちゃん",
+			"様", "方", "人", "時", "中", "上", "下",
+		},
+		Patterns: []*regexp.Regexp{
+			regexp.MustCompile(`^[ぁ-ん]$`), // ひらがな1文字
+			regexp.MustCompile(`^[0-9０-９]+$`),
+		},
+	}
+}
+
+// englishStopWords は英語のストップワード
+func englishStopWords() *StopWords {
+	return &StopWords{
+		Words: []string{
+			"a", "about", "above", "after", "again", "against", "all", "am", "an", "and", "any",
+			"are", "as", "at", "be", "because", "been", "before", "being", "below", "between",
+			"both", "but", "by", "can", "could", "did", "do", "does", "doing", "down", "during",
+			"each", "few", "for", "from", "further", "had", "has", "have", "having", "he", "her",
+			"here", "hers", "herself", "him", "himself", "his", "how", "i", "if", "in", "into",
+			"is", "it", "its", "itself", "just", "me", "more", "most", "my", "myself", "no", "nor",
+			"not", "now", "of", "off", "on", "once", "only", "or", "other", "our", "ours",
+			"ourselves", "out", "over", "own", "same", "she", "should", "so", "some", "such",
+			"than", "that", "the", "their", "theirs", "them", "themselves", "then", "there",
+			"these", "they", "this", "those", "through", "to", "too", "under", "until", "up",
+			"very", "was", "we", "were", "what", "when", "where", "which", "while", "who", "whom",
+			"why", "will", "with", "would", "you", "your", "yours", "yourself", "yourselves",
+		},
+	}
+}
+
+// slackStopWords はSlackの挨拶や定型的なやり取りに現れる単語
+func slackStopWords() *StopWords {
+	return &StopWords{
+		Words: []string{
+			"お疲れ様", "お疲れさま", "おつかれさま", "疲れ", "お疲れ", "よろしく", "宜しく",
+			"お願い", "願う", "お願いします", "ありがとう", "ござる", "ございます", "有難う",
+			"了解", "承知", "かしこまる", "おはよう", "こんにちは", "すみません", "すいません",
+			"失礼", "いたす", "いただく", "くださる", "頂く", "下さる",
+		},
+		Prefixes: []string{
+			":+1", ":pray", ":bow", ":ok", ":thumbsup", ":white_check_mark",
+		},
+	}
+}
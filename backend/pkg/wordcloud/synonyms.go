@@ -0,0 +1,121 @@
+package wordcloud
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Synonyms は別名（小文字化済み）から正規形への対応表
+type Synonyms map[string]string
+
+// maxSynonymTokens は別名として結合を試みる連続トークンの最大数
+// "k8s" のように形態素解析で分割される別名に対応するため
+const maxSynonymTokens = 4
+
+// Add は正規形と別名の対応を追加
+func (s Synonyms) Add(canonical string, aliases ...string) {
+	s[strings.ToLower(canonical)] = canonical
+	for _, alias := range aliases {
+		alias = strings.TrimSpace(alias)
+		if alias == "" {
+			continue
+		}
+		s[strings.ToLower(alias)] = canonical
+	}
+}
+
+// Canonical は単語の正規形を返す。対応がなければ空文字列とfalseを返す
+func (s Synonyms) Canonical(word string) (string, bool) {
+	canonical, ok := s[strings.ToLower(word)]
+	return canonical, ok
+}
+
+// ParseSynonyms はテキストから同義語の対応表を読み込む
+// 1行に "正規形 = 別名1, 別名2" の形式で記述し、"#" で始まる行はコメントとして扱う
+func ParseSynonyms(r io.Reader) (Synonyms, error) {
+	s := make(Synonyms)
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		canonical, aliases, ok := strings.Cut(line, "=")
+		canonical = strings.TrimSpace(canonical)
+		if !ok || canonical == "" {
+			return nil, fmt.Errorf("%d行目の形式が不正です: %q", lineNo, line)
+		}
+		s.Add(canonical, strings.Split(aliases, ",")...)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("同義語の読み込みに失敗: %w", err)
+	}
+	return s, nil
+}
+
+// LoadSynonymFile はファイルから同義語の対応表を読み込む
+func LoadSynonymFile(path string) (Synonyms, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("同義語ファイルのオープンに失敗: %w", err)
+	}
+	defer file.Close()
+
+	s, err := ParseSynonyms(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return s, nil
+}
+
+// foldSynonyms はトークン列の別名を正規形に置き換える
+// 隣接するトークンを結合した表層形が別名に一致する場合は1つのトークンにまとめる
+func (s Synonyms) foldSynonyms(tokens []Token) []Token {
+	if len(s) == 0 {
+		return tokens
+	}
+
+	folded := make([]Token, 0, len(tokens))
+	for i := 0; i < len(tokens); {
+		matched := 0
+		var canonical, variant string
+
+		surface := ""
+		for j := i; j < len(tokens) && j-i < maxSynonymTokens; j++ {
+			if j > i && tokens[j].Start != tokens[j-1].End {
+				break
+			}
+			surface += tokens[j].Surface
+			if c, ok := s.Canonical(surface); ok {
+				matched, canonical, variant = j-i+1, c, surface
+			}
+		}
+		if matched == 0 {
+			if c, ok := s.Canonical(tokens[i].BaseForm); ok {
+				matched, canonical, variant = 1, c, tokens[i].BaseForm
+			}
+		}
+
+		if matched == 0 {
+			folded = append(folded, tokens[i])
+			i++
+			continue
+		}
+
+		folded = append(folded, Token{
+			Surface:  variant,
+			BaseForm: canonical,
+			POS:      tokens[i].POS,
+			Start:    tokens[i].Start,
+			End:      tokens[i+matched-1].End,
+		})
+		i += matched
+	}
+	return folded
+}
@@ -0,0 +1,385 @@
+package wordcloud
+
+import (
+	"encoding/csv"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/fogleman/gg"
+)
+
+// TopicOptions はトピックモデル（LDA）の設定
+type TopicOptions struct {
+	Topics     int     // トピック数
+	Iterations int     // ギブスサンプリングの反復回数
+	Alpha      float64 // 文書のトピック分布のディリクレ事前分布（0以下は50/トピック数）
+	Beta       float64 // トピックの単語分布のディリクレ事前分布
+	TopWords   int     // トピックごとに出力する上位の単語数
+	Seed       int64   // 乱数のシード（同じシードと入力からは同じ結果になる）
+
+	Progress Progress // 推定の進捗の通知先（nilの場合は通知しない）
+}
+
+// DefaultTopicOptions はデフォルトのトピックモデル設定を返す
+func DefaultTopicOptions() TopicOptions {
+	return TopicOptions{
+		Topics:     5,
+		Iterations: 200,
+		Beta:       0.01,
+		TopWords:   30,
+		Seed:       1,
+	}
+}
+
+// Topic は1つのトピック
+type Topic struct {
+	ID     int         `json:"id"`     // トピック番号（0始まり）
+	Weight float64     `json:"weight"` // 全単語のうちこのトピックに割り当てられた割合
+	Words  []WordCount `json:"words"`  // 上位の単語（Scoreはトピック中の単語の出現確率）
+}
+
+// MessageTopics は1つのメッセージのトピック分布
+type MessageTopics struct {
+	Row          int       `json:"row"`          // 入力での行番号
+	ID           string    `json:"id"`           // メッセージID
+	UserID       string    `json:"userId"`       // 投稿者のユーザーID
+	Distribution []float64 `json:"distribution"` // トピックごとの割合（Topicsと同じ順序）
+	Top          int       `json:"top"`          // 割合が最も大きいトピック
+}
+
+// TopicModel はトピックモデルの推定結果
+type TopicModel struct {
+	Topics   []Topic         `json:"topics"`
+	Messages []MessageTopics `json:"messages"` // 単語を含むメッセージごとのトピック分布
+}
+
+// Topics はメッセージを文書とみなしてLDAのトピックを推定する
+// 推定には崩壊型ギブスサンプリングを使い、Config.MinCount未満の単語は語彙から除く
+func (g *Generator) Topics(messages []Message, opts TopicOptions) (*TopicModel, error) {
+	if opts.Topics < 1 {
+		return nil, fmt.Errorf("トピック数は1以上を指定してください: %d", opts.Topics)
+	}
+	k := opts.Topics
+	alpha := opts.Alpha
+	if alpha <= 0 {
+		alpha = 50 / float64(k)
+	}
+	beta := opts.Beta
+	if beta <= 0 {
+		beta = 0.01
+	}
+
+	// メッセージを単語の列に変換
+	messages = ExcludeUsers(messages, g.config.ExcludeUsers...)
+	docWords := make([][]string, len(messages))
+	totals := make(map[string]int)
+	for i, msg := range messages {
+		for _, tokens := range g.analyzeSentences(msg.Text) {
+			for _, token := range tokens {
+				docWords[i] = append(docWords[i], token.BaseForm)
+				totals[token.BaseForm]++
+			}
+		}
+	}
+
+	// 語彙を作成（出現順に依存しないよう辞書順に番号を振る）
+	var vocab []string
+	for word, count := range totals {
+		if count >= g.config.MinCount {
+			vocab = append(vocab, word)
+		}
+	}
+	sort.Strings(vocab)
+	if len(vocab) == 0 {
+		return nil, fmt.Errorf("トピックの推定に失敗: %w", ErrNoWords)
+	}
+	wordID := make(map[string]int, len(vocab))
+	for i, word := range vocab {
+		wordID[word] = i
+	}
+
+	var docs [][]int
+	var docMessages []Message
+	for i, words := range docWords {
+		var ids []int
+		for _, word := range words {
+			if id, ok := wordID[word]; ok {
+				ids = append(ids, id)
+			}
+		}
+		if len(ids) > 0 {
+			docs = append(docs, ids)
+			docMessages = append(docMessages, messages[i])
+		}
+	}
+
+	g.logger.Debug("トピックの推定を開始します", "documents", len(docs), "vocabulary", len(vocab), "topics", k)
+	start := time.Now()
+	sampler := newLDA(docs, len(vocab), k, alpha, beta, opts.Seed)
+	reporter := newProgressReporter(opts.Progress, StageTopics, opts.Iterations)
+	for iter := 0; iter < opts.Iterations; iter++ {
+		sampler.sample()
+		reporter.report(iter + 1)
+	}
+
+	model := &TopicModel{}
+	totalTokens := 0
+	for _, n := range sampler.nk {
+		totalTokens += n
+	}
+	for t := 0; t < k; t++ {
+		topic := Topic{ID: t, Weight: float64(sampler.nk[t]) / float64(totalTokens)}
+		for w, word := range vocab {
+			if sampler.nkw[t][w] > 0 {
+				topic.Words = append(topic.Words, WordCount{Text: word, Count: sampler.nkw[t][w], Score: sampler.phi(t, w)})
+			}
+		}
+		sort.Slice(topic.Words, func(i, j int) bool {
+			if topic.Words[i].Score != topic.Words[j].Score {
+				return topic.Words[i].Score > topic.Words[j].Score
+			}
+			return topic.Words[i].Text < topic.Words[j].Text
+		})
+		if opts.TopWords > 0 && len(topic.Words) > opts.TopWords {
+			topic.Words = topic.Words[:opts.TopWords]
+		}
+		if len(topic.Words) > 0 {
+			maxScore := topic.Words[0].Score
+			for i := range topic.Words {
+				topic.Words[i].FontSize = g.linearFontSize(math.Sqrt(topic.Words[i].Score / maxScore))
+			}
+		}
+		model.Topics = append(model.Topics, topic)
+	}
+
+	// トピックごとに色スキームの色を割り当てる（割り当てられた単語の多いトピックほど値が大きい）
+	series := make([]WordCount, k)
+	for t := range series {
+		series[t] = WordCount{Text: fmt.Sprintf("トピック%d", t+1), Count: sampler.nk[t]}
+	}
+	colors, err := g.seriesColors(series)
+	if err != nil {
+		return nil, err
+	}
+	for t, topic := range model.Topics {
+		for i := range topic.Words {
+			topic.Words[i].Color = colors[t]
+		}
+	}
+
+	for d, msg := range docMessages {
+		mt := MessageTopics{Row: msg.Row, ID: msg.ID, UserID: msg.UserID, Distribution: make([]float64, k)}
+		for t := 0; t < k; t++ {
+			mt.Distribution[t] = sampler.theta(d, t)
+			if mt.Distribution[t] > mt.Distribution[mt.Top] {
+				mt.Top = t
+			}
+		}
+		model.Messages = append(model.Messages, mt)
+	}
+
+	g.logger.Info("トピックの推定が完了しました", "documents", len(docs), "topics", k, "duration", time.Since(start))
+	return model, nil
+}
+
+// lda は崩壊型ギブスサンプリングによるLDAの状態
+type lda struct {
+	docs        [][]int // 文書ごとの単語IDの列
+	z           [][]int // 単語ごとに割り当てたトピック
+	ndk         [][]int // 文書ごとのトピックの割り当て数
+	nkw         [][]int // トピックごとの単語の割り当て数
+	nk          []int   // トピックごとの割り当て数の合計
+	k, v        int
+	alpha, beta float64
+	rng         *rand.Rand
+	probs       []float64
+}
+
+// newLDA はトピックをランダムに割り当てた初期状態を作成
+func newLDA(docs [][]int, vocabSize, k int, alpha, beta float64, seed int64) *lda {
+	m := &lda{
+		docs:  docs,
+		z:     make([][]int, len(docs)),
+		ndk:   make([][]int, len(docs)),
+		nkw:   make([][]int, k),
+		nk:    make([]int, k),
+		k:     k,
+		v:     vocabSize,
+		alpha: alpha,
+		beta:  beta,
+		rng:   rand.New(rand.NewSource(seed)),
+		probs: make([]float64, k),
+	}
+	for t := range m.nkw {
+		m.nkw[t] = make([]int, vocabSize)
+	}
+	for d, doc := range docs {
+		m.z[d] = make([]int, len(doc))
+		m.ndk[d] = make([]int, k)
+		for i, w := range doc {
+			t := m.rng.Intn(k)
+			m.z[d][i] = t
+			m.ndk[d][t]++
+			m.nkw[t][w]++
+			m.nk[t]++
+		}
+	}
+	return m
+}
+
+// sample は全単語のトピックを1回ずつ再サンプリングする
+func (m *lda) sample() {
+	vBeta := float64(m.v) * m.beta
+	for d, doc := range m.docs {
+		for i, w := range doc {
+			t := m.z[d][i]
+			m.ndk[d][t]--
+			m.nkw[t][w]--
+			m.nk[t]--
+
+			sum := 0.0
+			for k := 0; k < m.k; k++ {
+				sum += (float64(m.ndk[d][k]) + m.alpha) * (float64(m.nkw[k][w]) + m.beta) / (float64(m.nk[k]) + vBeta)
+				m.probs[k] = sum
+			}
+			u := m.rng.Float64() * sum
+			t = sort.SearchFloat64s(m.probs, u)
+			if t >= m.k {
+				t = m.k - 1
+			}
+
+			m.z[d][i] = t
+			m.ndk[d][t]++
+			m.nkw[t][w]++
+			m.nk[t]++
+		}
+	}
+}
+
+// theta は文書dのトピックtの割合を返す
+func (m *lda) theta(d, t int) float64 {
+	return (float64(m.ndk[d][t]) + m.alpha) / (float64(len(m.docs[d])) + float64(m.k)*m.alpha)
+}
+
+// phi はトピックtでの単語wの出現確率を返す
+func (m *lda) phi(t, w int) float64 {
+	return (float64(m.nkw[t][w]) + m.beta) / (float64(m.nk[t]) + float64(m.v)*m.beta)
+}
+
+// TopicsForCSV はCSVファイルを読み込んでトピックを推定（opts.Progressには読み込みの進捗も通知する）
+func (fp *FileProcessor) TopicsForCSV(inputPath string, messageColumn int, opts TopicOptions) (*TopicModel, error) {
+	messages, err := fp.ReadCSV(inputPath, messageColumn, WithProgress(opts.Progress))
+	if err != nil {
+		return nil, err
+	}
+	return fp.generator.Topics(messages, opts)
+}
+
+// ExportTopicsJSON はトピックモデルの推定結果をJSONファイルに出力
+func (fp *FileProcessor) ExportTopicsJSON(model *TopicModel, outputPath string) error {
+	return writeJSON(model, outputPath)
+}
+
+// ExportTopicsCSV はメッセージごとのトピック分布を1行1メッセージのCSVファイルに出力
+func (fp *FileProcessor) ExportTopicsCSV(model *TopicModel, outputPath string) error {
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		return fmt.Errorf("出力ディレクトリの作成に失敗: %w", err)
+	}
+
+	file, err := os.Create(outputPath)
+	if err != nil {
+		return fmt.Errorf("出力ファイルの作成に失敗: %w", err)
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+
+	header := []string{"Row", "ID", "UserID"}
+	for _, topic := range model.Topics {
+		header = append(header, fmt.Sprintf("Topic%d", topic.ID+1))
+	}
+	header = append(header, "Top")
+	if err := writer.Write(header); err != nil {
+		return fmt.Errorf("ヘッダーの書き込みに失敗: %w", err)
+	}
+
+	for _, mt := range model.Messages {
+		record := []string{strconv.Itoa(mt.Row), mt.ID, mt.UserID}
+		for _, p := range mt.Distribution {
+			record = append(record, strconv.FormatFloat(p, 'f', 4, 64))
+		}
+		record = append(record, strconv.Itoa(mt.Top+1))
+		if err := writer.Write(record); err != nil {
+			return fmt.Errorf("レコードの書き込みに失敗: %w", err)
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("CSVの書き込みに失敗: %w", err)
+	}
+	return nil
+}
+
+// ExportTopicsPNG はトピックごとの小さなワードクラウドを格子状に並べたPNG画像を出力
+func (fp *FileProcessor) ExportTopicsPNG(model *TopicModel, outputPath string) error {
+	if len(model.Topics) == 0 {
+		return fmt.Errorf("出力するトピックがありません")
+	}
+
+	font, err := fp.loadFont()
+	if err != nil {
+		return err
+	}
+
+	cols := int(math.Ceil(math.Sqrt(float64(len(model.Topics)))))
+	rows := (len(model.Topics) + cols - 1) / cols
+	width, height := float64(fp.config.Width), float64(fp.config.Height)
+	cellW, cellH := width/float64(cols), height/float64(rows)
+
+	// セルの面積に合わせてフォントサイズを縮小する
+	const headerHeight, minFontSize = 20.0, 8
+	scale := math.Sqrt(cellW * (cellH - headerHeight) / (width * height))
+
+	dc := gg.NewContext(fp.config.Width, fp.config.Height)
+	dc.SetColor(parseHexColor(fp.config.background()))
+	dc.Clear()
+
+	for i, topic := range model.Topics {
+		x := float64(i%cols) * cellW
+		y := float64(i/cols) * cellH
+
+		dc.SetFontFace(newFace(font, 12))
+		dc.SetHexColor(fp.config.textColor())
+		dc.DrawStringAnchored(fmt.Sprintf("トピック%d（%.0f%%）", topic.ID+1, topic.Weight*100), x+8, y+headerHeight/2, 0, 0.5)
+
+		dc.SetHexColor("#DDDDDD")
+		dc.SetLineWidth(1)
+		dc.DrawRectangle(x+0.5, y+0.5, cellW-1, cellH-1)
+		dc.Stroke()
+
+		words := make([]WordCount, len(topic.Words))
+		for j, word := range topic.Words {
+			word.FontSize = max(minFontSize, int(float64(word.FontSize)*scale))
+			words[j] = word
+		}
+		region := Rectangle{X: x, Y: y + headerHeight, W: cellW, H: cellH - headerHeight}
+		fp.drawWords(dc, font, words, region, func(word WordCount) string { return word.Color })
+	}
+
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		return fmt.Errorf("出力ディレクトリの作成に失敗: %w", err)
+	}
+	if err := dc.SavePNG(outputPath); err != nil {
+		return fmt.Errorf("PNG画像の保存に失敗: %w", err)
+	}
+
+	fp.generator.logger.Info("トピックの画像を出力しました", "path", outputPath)
+	return nil
+}
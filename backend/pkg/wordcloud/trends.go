@@ -0,0 +1,357 @@
+package wordcloud
+
+import (
+	"encoding/csv"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/fogleman/gg"
+)
+
+// 時系列で集計する期間の単位
+const (
+	PeriodDay   = "day"
+	PeriodWeek  = "week"
+	PeriodMonth = "month"
+)
+
+// 単語の傾向
+const (
+	TrendRising  = "rising"
+	TrendFalling = "falling"
+	TrendStable  = "stable"
+)
+
+// trendThreshold は1期間あたりの相対的な変化率がこの値を超えると増加・減少とみなす
+const trendThreshold = 0.1
+
+// TrendSeries は1つの単語の期間ごとの出現回数
+type TrendSeries struct {
+	Word   string  `json:"word"`   // 単語
+	Counts []int   `json:"counts"` // 期間ごとの出現回数（Trends.Bucketsと同じ順序）
+	Total  int     `json:"total"`  // 全期間の出現回数
+	Slope  float64 `json:"slope"`  // 相対頻度の回帰直線の傾きを平均で割った1期間あたりの変化率
+	Trend  string  `json:"trend"`  // 傾向（rising/falling/stable）
+}
+
+// Trends は期間ごとの単語の出現回数の推移
+type Trends struct {
+	Period  string        `json:"period"`  // 期間の単位
+	Buckets []time.Time   `json:"buckets"` // 各期間の開始日時
+	Totals  []int         `json:"totals"`  // 期間ごとの全単語の出現回数
+	Series  []TrendSeries `json:"series"`  // 出現回数の多い順の単語ごとの推移
+}
+
+// periodStart は日時が属する期間の開始日時を返す（週は月曜日始まり）
+func periodStart(t time.Time, period string) time.Time {
+	y, m, d := t.Date()
+	switch period {
+	case PeriodWeek:
+		offset := (int(t.Weekday()) + 6) % 7
+		return time.Date(y, m, d-offset, 0, 0, 0, 0, t.Location())
+	case PeriodMonth:
+		return time.Date(y, m, 1, 0, 0, 0, 0, t.Location())
+	default:
+		return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+	}
+}
+
+// nextPeriod は次の期間の開始日時を返す
+func nextPeriod(t time.Time, period string) time.Time {
+	switch period {
+	case PeriodWeek:
+		return t.AddDate(0, 0, 7)
+	case PeriodMonth:
+		return t.AddDate(0, 1, 0)
+	default:
+		return t.AddDate(0, 0, 1)
+	}
+}
+
+// Trends はメッセージを期間ごとに分けて単語の出現回数の推移を集計
+// 期間の区切りはConfig.TimeLocationのタイムゾーンで判定する
+func (g *Generator) Trends(messages []Message, period string) (*Trends, error) {
+	switch period {
+	case PeriodDay, PeriodWeek, PeriodMonth:
+	default:
+		return nil, fmt.Errorf("不明な期間の単位です: %s", period)
+	}
+
+	loc := g.config.location()
+	bucketCounts := make(map[time.Time]map[string]int)
+	bucketTotals := make(map[time.Time]int)
+	totals := make(map[string]int)
+	skipped := 0
+
+	for _, msg := range ExcludeUsers(messages, g.config.ExcludeUsers...) {
+		if msg.Timestamp.IsZero() {
+			skipped++
+			continue
+		}
+		bucket := periodStart(msg.Timestamp.In(loc), period)
+		counts, ok := bucketCounts[bucket]
+		if !ok {
+			counts = make(map[string]int)
+			bucketCounts[bucket] = counts
+		}
+		for _, tokens := range g.analyzeSentences(msg.Text) {
+			for _, token := range tokens {
+				counts[token.BaseForm]++
+				totals[token.BaseForm]++
+				bucketTotals[bucket]++
+			}
+		}
+	}
+	if skipped > 0 {
+		g.logger.Warn("日時を判定できないメッセージを除外しました", "count", skipped)
+	}
+	if len(bucketCounts) == 0 {
+		return nil, fmt.Errorf("日時を持つメッセージがありません")
+	}
+
+	// 最初から最後までの期間を空きなく並べる
+	var first, last time.Time
+	for bucket := range bucketCounts {
+		if first.IsZero() || bucket.Before(first) {
+			first = bucket
+		}
+		if bucket.After(last) {
+			last = bucket
+		}
+	}
+	t := &Trends{Period: period}
+	for b := first; !b.After(last); b = nextPeriod(b, period) {
+		t.Buckets = append(t.Buckets, b)
+		t.Totals = append(t.Totals, bucketTotals[b])
+	}
+
+	for word, total := range totals {
+		if total < g.config.MinCount {
+			continue
+		}
+		series := TrendSeries{Word: word, Total: total, Counts: make([]int, len(t.Buckets))}
+		for i, b := range t.Buckets {
+			series.Counts[i] = bucketCounts[b][word]
+		}
+		series.Slope = relativeSlope(series.Counts, t.Totals)
+		series.Trend = classifyTrend(series.Slope)
+		t.Series = append(t.Series, series)
+	}
+
+	sort.Slice(t.Series, func(i, j int) bool {
+		if t.Series[i].Total != t.Series[j].Total {
+			return t.Series[i].Total > t.Series[j].Total
+		}
+		return t.Series[i].Word < t.Series[j].Word
+	})
+	if len(t.Series) > g.config.MaxWords {
+		t.Series = t.Series[:g.config.MaxWords]
+	}
+
+	g.logger.Info("時系列の集計が完了しました", "period", period, "buckets", len(t.Buckets), "words", len(t.Series))
+	return t, nil
+}
+
+// relativeSlope は期間ごとの相対頻度に回帰直線を当てはめ、傾きを平均値で割った値を返す
+func relativeSlope(counts, totals []int) float64 {
+	n := float64(len(counts))
+	if n < 2 {
+		return 0
+	}
+
+	freqs := make([]float64, len(counts))
+	mean := 0.0
+	for i, c := range counts {
+		if totals[i] > 0 {
+			freqs[i] = float64(c) / float64(totals[i])
+		}
+		mean += freqs[i]
+	}
+	mean /= n
+	if mean == 0 {
+		return 0
+	}
+
+	xMean := (n - 1) / 2
+	var num, den float64
+	for i, f := range freqs {
+		dx := float64(i) - xMean
+		num += dx * (f - mean)
+		den += dx * dx
+	}
+	return num / den / mean
+}
+
+// classifyTrend は変化率から傾向を判定
+func classifyTrend(slope float64) string {
+	switch {
+	case slope > trendThreshold:
+		return TrendRising
+	case slope < -trendThreshold:
+		return TrendFalling
+	default:
+		return TrendStable
+	}
+}
+
+// bucketLabel は期間の表示用ラベルを返す
+func (t *Trends) bucketLabel(i int) string {
+	if t.Period == PeriodMonth {
+		return t.Buckets[i].Format("2006-01")
+	}
+	return t.Buckets[i].Format("2006-01-02")
+}
+
+// TrendsForCSV はCSVファイルを読み込んで単語の出現回数の推移を集計
+func (fp *FileProcessor) TrendsForCSV(inputPath string, messageColumn int, period string) (*Trends, error) {
+	messages, err := fp.ReadCSV(inputPath, messageColumn)
+	if err != nil {
+		return nil, err
+	}
+	return fp.generator.Trends(messages, period)
+}
+
+// ExportTrendsJSON は単語の推移をJSONファイルに出力
+func (fp *FileProcessor) ExportTrendsJSON(t *Trends, outputPath string) error {
+	return writeJSON(t, outputPath)
+}
+
+// ExportTrendsCSV は単語の推移を1行1単語のCSVファイルに出力
+func (fp *FileProcessor) ExportTrendsCSV(t *Trends, outputPath string) error {
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		return fmt.Errorf("出力ディレクトリの作成に失敗: %w", err)
+	}
+
+	file, err := os.Create(outputPath)
+	if err != nil {
+		return fmt.Errorf("出力ファイルの作成に失敗: %w", err)
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+
+	header := []string{"Word"}
+	for i := range t.Buckets {
+		header = append(header, t.bucketLabel(i))
+	}
+	header = append(header, "Total", "Slope", "Trend")
+	if err := writer.Write(header); err != nil {
+		return fmt.Errorf("ヘッダーの書き込みに失敗: %w", err)
+	}
+
+	for _, s := range t.Series {
+		record := []string{s.Word}
+		for _, c := range s.Counts {
+			record = append(record, strconv.Itoa(c))
+		}
+		record = append(record, strconv.Itoa(s.Total), strconv.FormatFloat(s.Slope, 'f', 4, 64), s.Trend)
+		if err := writer.Write(record); err != nil {
+			return fmt.Errorf("レコードの書き込みに失敗: %w", err)
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("CSVの書き込みに失敗: %w", err)
+	}
+	return nil
+}
+
+// ExportTrendsPNG は出現回数の多い上位maxSeries語の推移を折れ線グラフとしてPNG画像に出力
+func (fp *FileProcessor) ExportTrendsPNG(t *Trends, outputPath string, maxSeries int) error {
+	series := t.Series
+	if maxSeries > 0 && len(series) > maxSeries {
+		series = series[:maxSeries]
+	}
+
+	font, err := fp.loadFont()
+	if err != nil {
+		return err
+	}
+	colors, err := fp.generator.seriesColors(trendSeriesWords(series))
+	if err != nil {
+		return err
+	}
+	textColor := fp.config.textColor()
+
+	width, height := float64(fp.config.Width), float64(fp.config.Height)
+	dc := gg.NewContext(fp.config.Width, fp.config.Height)
+	dc.SetColor(parseHexColor(fp.config.background()))
+	dc.Clear()
+	dc.SetFontFace(newFace(font, 11))
+
+	// 描画領域（右側は凡例）
+	const marginLeft, marginTop, marginBottom, legendWidth = 48.0, 16.0, 40.0, 140.0
+	plot := Rectangle{X: marginLeft, Y: marginTop, W: width - marginLeft - legendWidth, H: height - marginTop - marginBottom}
+
+	maxCount := 1
+	for _, s := range series {
+		for _, c := range s.Counts {
+			maxCount = max(maxCount, c)
+		}
+	}
+	xOf := func(i int) float64 {
+		if len(t.Buckets) < 2 {
+			return plot.X + plot.W/2
+		}
+		return plot.X + plot.W*float64(i)/float64(len(t.Buckets)-1)
+	}
+	yOf := func(c int) float64 {
+		return plot.Y + plot.H*(1-float64(c)/float64(maxCount))
+	}
+
+	// 軸と目盛り
+	dc.SetHexColor(textColor)
+	dc.SetLineWidth(1)
+	dc.DrawLine(plot.X, plot.Y, plot.X, plot.Y+plot.H)
+	dc.DrawLine(plot.X, plot.Y+plot.H, plot.X+plot.W, plot.Y+plot.H)
+	dc.Stroke()
+	for _, c := range []int{0, maxCount / 2, maxCount} {
+		dc.DrawStringAnchored(strconv.Itoa(c), plot.X-6, yOf(c), 1, 0.5)
+	}
+	step := int(math.Ceil(float64(len(t.Buckets)) / 8))
+	for i := 0; i < len(t.Buckets); i += step {
+		dc.DrawStringAnchored(t.bucketLabel(i), xOf(i), plot.Y+plot.H+14, 0.5, 0.5)
+	}
+
+	// 系列と凡例
+	for k, s := range series {
+		dc.SetHexColor(colors[k])
+		dc.SetLineWidth(2)
+		for i, c := range s.Counts {
+			if i == 0 {
+				dc.MoveTo(xOf(i), yOf(c))
+			} else {
+				dc.LineTo(xOf(i), yOf(c))
+			}
+		}
+		dc.Stroke()
+
+		legendY := plot.Y + 8 + float64(k)*18
+		dc.DrawLine(width-legendWidth+8, legendY, width-legendWidth+28, legendY)
+		dc.Stroke()
+		dc.SetHexColor(textColor)
+		dc.DrawStringAnchored(s.Word, width-legendWidth+34, legendY, 0, 0.5)
+	}
+
+	if err := dc.SavePNG(outputPath); err != nil {
+		return fmt.Errorf("PNG画像の保存に失敗: %w", err)
+	}
+
+	fp.generator.logger.Info("推移グラフを出力しました", "path", outputPath)
+	return nil
+}
+
+// trendSeriesWords は色スキームで系列の色を決めるため、系列を全期間の出現回数の単語として返す
+func trendSeriesWords(series []TrendSeries) []WordCount {
+	words := make([]WordCount, len(series))
+	for i, s := range series {
+		words[i] = WordCount{Text: s.Word, Count: s.Total}
+	}
+	return words
+}
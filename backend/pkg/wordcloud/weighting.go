@@ -0,0 +1,204 @@
+package wordcloud
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// 単語の重み付け方式
+const (
+	WeightingCount         = "count"         // 出現回数
+	WeightingTFIDF         = "tfidf"         // TF-IDF
+	WeightingLogLikelihood = "loglikelihood" // 参照コーパスに対する対数尤度比
+)
+
+// TF-IDFで文書とみなす単位
+const (
+	DocumentMessage = "message" // メッセージ単位
+	DocumentThread  = "thread"  // スレッド単位
+	DocumentDay     = "day"     // 日単位
+)
+
+// validateWeighting は重み付けの設定を検証
+func (c Config) validateWeighting() error {
+	switch c.Weighting {
+	case "", WeightingCount:
+	case WeightingTFIDF:
+		switch c.DocumentUnit {
+		case "", DocumentMessage, DocumentThread, DocumentDay:
+		default:
+			return fmt.Errorf("不明な文書の単位です: %s", c.DocumentUnit)
+		}
+	case WeightingLogLikelihood:
+		if c.Reference == nil || c.Reference.Total == 0 {
+			return fmt.Errorf("対数尤度比による重み付けには参照コーパスが必要です")
+		}
+	default:
+		return fmt.Errorf("不明な重み付け方式です: %s", c.Weighting)
+	}
+	return nil
+}
+
+// documentKey はメッセージが属する文書を識別する値を返す
+// メッセージIDや日時がない場合（CSVにTimestamp列がないなど）は行番号でメッセージを区別する
+func (c Config) documentKey(msg Message) string {
+	switch c.DocumentUnit {
+	case DocumentDay:
+		if !msg.Timestamp.IsZero() {
+			return msg.Timestamp.In(c.location()).Format("2006-01-02")
+		}
+	case DocumentThread:
+		if key := msg.ThreadKey(); key != "" {
+			return key
+		}
+	default:
+		if msg.ID != "" {
+			return msg.ID
+		}
+	}
+	return "row:" + strconv.Itoa(msg.Row)
+}
+
+// documentFrequency は単語ごとに出現した文書の数を集計する
+type documentFrequency struct {
+	counts    map[string]int
+	documents map[string]map[string]bool
+}
+
+// newDocumentFrequency は新しいdocumentFrequencyを作成
+func newDocumentFrequency() *documentFrequency {
+	return &documentFrequency{
+		counts:    make(map[string]int),
+		documents: make(map[string]map[string]bool),
+	}
+}
+
+// add は文書docに単語wordが出現したことを記録
+func (df *documentFrequency) add(doc, word string) {
+	words, ok := df.documents[doc]
+	if !ok {
+		words = make(map[string]bool)
+		df.documents[doc] = words
+	}
+	if !words[word] {
+		words[word] = true
+		df.counts[word]++
+	}
+}
+
+// tfidf は単語の総出現回数と文書頻度からTF-IDFを計算
+// IDFは log((1+N)/(1+df)) + 1 で平滑化する
+func (df *documentFrequency) tfidf(word string, count int) float64 {
+	n := float64(len(df.documents))
+	idf := math.Log((1+n)/(1+float64(df.counts[word]))) + 1
+	return float64(count) * idf
+}
+
+// ReferenceCorpus は比較対象となる参照コーパスの単語頻度
+type ReferenceCorpus struct {
+	Counts map[string]int
+	Total  int
+}
+
+// NewReferenceCorpus は単語頻度から参照コーパスを作成
+func NewReferenceCorpus(counts map[string]int) *ReferenceCorpus {
+	r := &ReferenceCorpus{Counts: counts}
+	for _, count := range counts {
+		r.Total += count
+	}
+	return r
+}
+
+// ParseReferenceCorpus は "単語<TAB>出現回数" 形式のテキストから参照コーパスを読み込む
+// 区切り文字にはタブのほかカンマも使用でき、"#" で始まる行はコメントとして扱う
+func ParseReferenceCorpus(r io.Reader) (*ReferenceCorpus, error) {
+	counts := make(map[string]int)
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		sep := "\t"
+		if !strings.Contains(line, sep) {
+			sep = ","
+		}
+		word, value, ok := strings.Cut(line, sep)
+		if !ok {
+			return nil, fmt.Errorf("%d行目の形式が不正です: %q", lineNo, line)
+		}
+		count, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("%d行目の出現回数が不正です: %w", lineNo, err)
+		}
+		counts[strings.TrimSpace(word)] += count
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("参照コーパスの読み込みに失敗: %w", err)
+	}
+	return NewReferenceCorpus(counts), nil
+}
+
+// LoadReferenceCorpus はファイルから参照コーパスを読み込む
+// 拡張子が .json の場合はExportJSONで出力したワードクラウドデータとして読み込む
+func LoadReferenceCorpus(path string) (*ReferenceCorpus, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("参照コーパスのオープンに失敗: %w", err)
+	}
+	defer file.Close()
+
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		var words []WordCount
+		if err := json.NewDecoder(file).Decode(&words); err != nil {
+			return nil, fmt.Errorf("%s: 参照コーパスのJSONの解析に失敗: %w", path, err)
+		}
+		counts := make(map[string]int, len(words))
+		for _, w := range words {
+			counts[w.Text] += w.Count
+		}
+		return NewReferenceCorpus(counts), nil
+	}
+
+	r, err := ParseReferenceCorpus(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return r, nil
+}
+
+// logLikelihood は対象コーパスでの出現回数aと参照コーパスでの出現回数bから
+// Dunningの対数尤度比（G2）を計算する。対象コーパスで相対的に少ない単語は負の値を返す
+func logLikelihood(a, totalA, b, totalB int) float64 {
+	if totalA == 0 || totalB == 0 {
+		return 0
+	}
+	fa, fb := float64(a), float64(b)
+	ta, tb := float64(totalA), float64(totalB)
+	e1 := ta * (fa + fb) / (ta + tb)
+	e2 := tb * (fa + fb) / (ta + tb)
+
+	g2 := 0.0
+	if fa > 0 {
+		g2 += fa * math.Log(fa/e1)
+	}
+	if fb > 0 {
+		g2 += fb * math.Log(fb/e2)
+	}
+	g2 *= 2
+
+	if fa/ta < fb/tb {
+		return -g2
+	}
+	return g2
+}
@@ -3,6 +3,9 @@ export interface WordCloudItem {
     count: number;
     fontSize: number;
     color: string;
+    score?: number;
+    variants?: Record<string, number>;
+    sentiment?: number;
   }
   
   export interface WordCloudData {